@@ -0,0 +1,54 @@
+// index-entities adds the entities labelled before the label store kept an entity index to it,
+// so that the background sweep re-verifies them too. It scans the whole table once.
+//
+//	index-entities [-chain-id 1] [-bot-id 0x...] [-secrets secrets.json]
+//
+// The store is selected by the same LABEL_STORE* variables as the bot.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+
+	"forta-network/go-agent/store"
+)
+
+func main() {
+	chainID := flag.Int64("chain-id", 1, "chain id of the bot")
+	botID := flag.String("bot-id", os.Getenv("FORTA_BOT_ID"), "bot id owning the labels")
+	secretsFile := flag.String("secrets", "", "bot secrets file with the AWS credentials (default: load from the bot database)")
+	flag.Parse()
+
+	cfg, err := store.ConfigFromEnv()
+	if err != nil {
+		log.WithError(err).Fatal("invalid label store config")
+	}
+	secrets := &store.Secrets{}
+	if *secretsFile != "" {
+		secrets, err = store.LoadSecretsFromFile(*secretsFile)
+	} else if cfg.NeedsSecrets() {
+		secrets, err = store.LoadSecrets()
+	}
+	if err != nil {
+		log.WithError(err).Fatal("failed to load secrets")
+	}
+
+	ctx := context.Background()
+	s, err := store.Open(ctx, cfg, *chainID, *botID, secrets)
+	if err != nil {
+		log.WithError(err).Fatal("failed to init label store")
+	}
+	indexer, ok := s.(store.EntityIndexer)
+	if !ok {
+		log.WithField("backend", cfg.Backend).Info("label store has no entity index (nothing to do)")
+		return
+	}
+	n, err := indexer.IndexEntities(ctx)
+	if err != nil {
+		log.WithError(err).Fatal("failed to index entities")
+	}
+	log.WithField("entities", n).Info("indexed entities")
+}
@@ -0,0 +1,81 @@
+// purge-labels removes bad labels of an address from the label store, so that the bot publishes
+// them afresh (or not at all) the next time the address is scanned.
+//
+//	purge-labels [-chain-id 1] [-bot-id 0x...] [-secrets secrets.json] [-dry-run] <address> [label...]
+//
+// Without labels every label of the address is purged. The store is selected by the same
+// LABEL_STORE* variables as the bot.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+
+	"forta-network/go-agent/store"
+)
+
+// dryRunStore leaves the labels it is asked to delete in place
+type dryRunStore struct {
+	store.LabelStore
+}
+
+func (dryRunStore) DeleteLabel(ctx context.Context, entity, label string) error {
+	return nil
+}
+
+func main() {
+	chainID := flag.Int64("chain-id", 1, "chain id of the bot")
+	botID := flag.String("bot-id", os.Getenv("FORTA_BOT_ID"), "bot id owning the labels")
+	secretsFile := flag.String("secrets", "", "bot secrets file with the AWS credentials (default: load from the bot database)")
+	dryRun := flag.Bool("dry-run", false, "list the labels that would be purged")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <address> [label...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	entity, labels := flag.Arg(0), flag.Args()[1:]
+
+	cfg, err := store.ConfigFromEnv()
+	if err != nil {
+		log.WithError(err).Fatal("invalid label store config")
+	}
+	secrets := &store.Secrets{}
+	if *secretsFile != "" {
+		secrets, err = store.LoadSecretsFromFile(*secretsFile)
+	} else if cfg.NeedsSecrets() {
+		secrets, err = store.LoadSecrets()
+	}
+	if err != nil {
+		log.WithError(err).Fatal("failed to load secrets")
+	}
+
+	ctx := context.Background()
+	s, err := store.Open(ctx, cfg, *chainID, *botID, secrets)
+	if err != nil {
+		log.WithError(err).Fatal("failed to init label store")
+	}
+
+	if *dryRun {
+		s = dryRunStore{s}
+	}
+	purged, err := store.PurgeLabels(ctx, s, entity, labels...)
+	for _, l := range purged {
+		fmt.Println(l.Label)
+	}
+	if err != nil {
+		log.WithError(err).Fatal("failed to purge labels")
+	}
+	log.WithFields(log.Fields{
+		"entity": entity,
+		"purged": len(purged),
+		"dryRun": *dryRun,
+	}).Info("purged labels")
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"golang.org/x/exp/slices"
+	"strings"
 	"time"
 )
 
@@ -9,6 +10,90 @@ type AddressReport struct {
 	Name        string    `json:"name"`
 	LastChecked time.Time `json:"lastChecked"`
 	Tags        []string  `json:"tags"`
+	// Reputation is the explorer's token reputation: ok, neutral, suspicious or unsafe
+	Reputation string   `json:"reputation,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+	// explorer api data, only set when an api key is configured
+	ContractName string `json:"contractName,omitempty"`
+	// Implementation is the contract a proxy delegates to
+	Implementation string `json:"implementation,omitempty"`
+	// Provenance records where each tag was seen, by tag
+	Provenance map[string][]*Provenance `json:"provenance,omitempty"`
+	// Conflicts flags explorers that disagree on what the address is
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+	// Partial is set when some explorer couldn't be scanned, so tags missing from the report
+	// may still be shown there
+	Partial bool `json:"partial,omitempty"`
+}
+
+// Conflict is a tag category on one explorer that contradicts one on another explorer
+type Conflict struct {
+	Source        string `json:"source"`
+	Category      string `json:"category"`
+	OtherSource   string `json:"otherSource"`
+	OtherCategory string `json:"otherCategory"`
+}
+
+// Provenance is one page a tag was seen on
+type Provenance struct {
+	SourceURL string    `json:"sourceUrl"`
+	ParserID  string    `json:"parserId"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// AddProvenance records that the tag was seen on p's page, widening the seen times
+// when the page is already recorded
+func (ar *AddressReport) AddProvenance(tag string, p *Provenance) {
+	if ar.Provenance == nil {
+		ar.Provenance = make(map[string][]*Provenance)
+	}
+	for _, existing := range ar.Provenance[tag] {
+		if existing.SourceURL == p.SourceURL && existing.ParserID == p.ParserID {
+			if p.FirstSeen.Before(existing.FirstSeen) {
+				existing.FirstSeen = p.FirstSeen
+			}
+			if p.LastSeen.After(existing.LastSeen) {
+				existing.LastSeen = p.LastSeen
+			}
+			return
+		}
+	}
+	cp := *p
+	ar.Provenance[tag] = append(ar.Provenance[tag], &cp)
+}
+
+// FirstSeen returns when the tag was first seen on any page, zero if unknown
+func (ar *AddressReport) FirstSeen(tag string) time.Time {
+	var first time.Time
+	for t, ps := range ar.Provenance {
+		if !strings.EqualFold(t, tag) {
+			continue
+		}
+		for _, p := range ps {
+			if first.IsZero() || p.FirstSeen.Before(first) {
+				first = p.FirstSeen
+			}
+		}
+	}
+	return first
+}
+
+// KeepFirstSeen carries the first-seen times of a previous report of the same address over
+// to the tags that are still present, so that re-scans don't reset the age of a tag
+func (ar *AddressReport) KeepFirstSeen(previous *AddressReport) {
+	if previous == nil {
+		return
+	}
+	for tag, ps := range ar.Provenance {
+		for _, p := range ps {
+			for _, old := range previous.Provenance[tag] {
+				if old.SourceURL == p.SourceURL && old.ParserID == p.ParserID && old.FirstSeen.Before(p.FirstSeen) {
+					p.FirstSeen = old.FirstSeen
+				}
+			}
+		}
+	}
 }
 
 func (ar *AddressReport) Merge(other *AddressReport) {
@@ -18,11 +103,36 @@ func (ar *AddressReport) Merge(other *AddressReport) {
 	if ar.Name == "" {
 		ar.Name = other.Name
 	}
+	if ar.Reputation == "" {
+		ar.Reputation = other.Reputation
+	}
+	for _, w := range other.Warnings {
+		if !slices.Contains[string](ar.Warnings, w) {
+			ar.Warnings = append(ar.Warnings, w)
+		}
+	}
+	if ar.ContractName == "" {
+		ar.ContractName = other.ContractName
+	}
+	if ar.Implementation == "" {
+		ar.Implementation = other.Implementation
+	}
+	ar.Partial = ar.Partial || other.Partial
 
 	for _, t := range other.Tags {
 		if !slices.Contains[string](ar.Tags, t) {
 			ar.Tags = append(ar.Tags, t)
 		}
 	}
+	for _, c := range other.Conflicts {
+		if !slices.Contains(ar.Conflicts, c) {
+			ar.Conflicts = append(ar.Conflicts, c)
+		}
+	}
+	for t, ps := range other.Provenance {
+		for _, p := range ps {
+			ar.AddProvenance(t, p)
+		}
+	}
 
 }
@@ -24,3 +24,55 @@ func TestAddressReport_Merge(t *testing.T) {
 		Tags:        []string{"label1", "label2"},
 	}, ar1)
 }
+
+func TestAddressReport_MergeAPIFields(t *testing.T) {
+	ar1 := &AddressReport{
+		Name: "tether: usdt stablecoin",
+	}
+	ar2 := &AddressReport{
+		Name:         "tether usd",
+		ContractName: "tethertoken",
+	}
+	ar1.Merge(ar2)
+
+	assert.Equal(t, "tether: usdt stablecoin", ar1.Name)
+	assert.Equal(t, "tethertoken", ar1.ContractName)
+}
+
+func TestAddressReport_MergeProvenance(t *testing.T) {
+	time1 := time.Now().UTC().Add(-48 * time.Hour)
+	time2 := time.Now().UTC()
+	token := &Provenance{SourceURL: "https://etherscan.io/token/0x1", ParserID: "etherscan.io", FirstSeen: time2, LastSeen: time2}
+	address := &Provenance{SourceURL: "https://etherscan.io/address/0x1", ParserID: "etherscan.io", FirstSeen: time1, LastSeen: time1}
+
+	ar1 := &AddressReport{Tags: []string{"phish / hack"}}
+	ar1.AddProvenance("phish / hack", token)
+	ar2 := &AddressReport{Tags: []string{"phish / hack", "heist"}}
+	ar2.AddProvenance("phish / hack", address)
+	ar2.AddProvenance("heist", address)
+	ar1.Merge(ar2)
+
+	assert.Equal(t, []*Provenance{token, address}, ar1.Provenance["phish / hack"])
+	assert.Equal(t, []*Provenance{address}, ar1.Provenance["heist"])
+	assert.Equal(t, time1, ar1.FirstSeen("Phish / Hack"))
+	assert.True(t, ar1.FirstSeen("blocked").IsZero())
+
+	// the same page widens the seen times instead of being added twice
+	ar1.AddProvenance("heist", &Provenance{SourceURL: address.SourceURL, ParserID: address.ParserID, FirstSeen: time2, LastSeen: time2})
+	assert.Len(t, ar1.Provenance["heist"], 1)
+	assert.Equal(t, time1, ar1.Provenance["heist"][0].FirstSeen)
+	assert.Equal(t, time2, ar1.Provenance["heist"][0].LastSeen)
+}
+
+func TestAddressReport_KeepFirstSeen(t *testing.T) {
+	time1 := time.Now().UTC().Add(-48 * time.Hour)
+	time2 := time.Now().UTC()
+	previous := &AddressReport{}
+	previous.AddProvenance("heist", &Provenance{SourceURL: "u", ParserID: "p", FirstSeen: time1, LastSeen: time1})
+
+	ar := &AddressReport{}
+	ar.AddProvenance("heist", &Provenance{SourceURL: "u", ParserID: "p", FirstSeen: time2, LastSeen: time2})
+	ar.KeepFirstSeen(previous)
+	assert.Equal(t, time1, ar.FirstSeen("heist"))
+	assert.Equal(t, time2, ar.Provenance["heist"][0].LastSeen)
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"golang.org/x/exp/slices"
+)
+
+// ReportDiff is what changed between two scans of an address
+type ReportDiff struct {
+	AddedTags    []string `json:"addedTags,omitempty"`
+	RemovedTags  []string `json:"removedTags,omitempty"`
+	PreviousName string   `json:"previousName,omitempty"`
+	Name         string   `json:"name,omitempty"`
+
+	AddedWarnings        []string `json:"addedWarnings,omitempty"`
+	RemovedWarnings      []string `json:"removedWarnings,omitempty"`
+	PreviousReputation   string   `json:"previousReputation,omitempty"`
+	Reputation           string   `json:"reputation,omitempty"`
+	PreviousContractName string   `json:"previousContractName,omitempty"`
+	ContractName         string   `json:"contractName,omitempty"`
+	// PreviousImplementation and Implementation are the contracts a proxy delegated to
+	PreviousImplementation string `json:"previousImplementation,omitempty"`
+	Implementation         string `json:"implementation,omitempty"`
+}
+
+// NameChanged reports whether the name differs between the scans
+func (d *ReportDiff) NameChanged() bool {
+	return d.PreviousName != d.Name
+}
+
+// IsEmpty reports whether nothing that labels are built from changed
+func (d *ReportDiff) IsEmpty() bool {
+	return len(d.AddedTags) == 0 && len(d.RemovedTags) == 0 && !d.NameChanged() &&
+		len(d.AddedWarnings) == 0 && len(d.RemovedWarnings) == 0 &&
+		d.PreviousReputation == d.Reputation && d.PreviousContractName == d.ContractName &&
+		d.PreviousImplementation == d.Implementation
+}
+
+// Diff compares two scans of the same address; a nil report has no name, tags or banners
+func Diff(previous, current *AddressReport) *ReportDiff {
+	if previous == nil {
+		previous = &AddressReport{}
+	}
+	if current == nil {
+		current = &AddressReport{}
+	}
+	d := &ReportDiff{
+		PreviousName:           previous.Name,
+		Name:                   current.Name,
+		PreviousReputation:     previous.Reputation,
+		Reputation:             current.Reputation,
+		PreviousContractName:   previous.ContractName,
+		ContractName:           current.ContractName,
+		PreviousImplementation: previous.Implementation,
+		Implementation:         current.Implementation,
+	}
+	d.AddedTags, d.RemovedTags = diffStrings(previous.Tags, current.Tags)
+	d.AddedWarnings, d.RemovedWarnings = diffStrings(previous.Warnings, current.Warnings)
+	return d
+}
+
+// diffStrings returns the values only in current, and those only in previous
+func diffStrings(previous, current []string) ([]string, []string) {
+	var added, removed []string
+	for _, v := range current {
+		if !slices.Contains(previous, v) {
+			added = append(added, v)
+		}
+	}
+	for _, v := range previous {
+		if !slices.Contains(current, v) {
+			removed = append(removed, v)
+		}
+	}
+	return added, removed
+}
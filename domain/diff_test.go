@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	previous := &AddressReport{
+		Name: "yearn (ydai) exploiter",
+		Tags: []string{"heist", "blocked"},
+	}
+	current := &AddressReport{
+		Name: "yearn exploiter 1",
+		Tags: []string{"heist", "phish / hack"},
+	}
+	d := Diff(previous, current)
+	assert.Equal(t, &ReportDiff{
+		AddedTags:    []string{"phish / hack"},
+		RemovedTags:  []string{"blocked"},
+		PreviousName: "yearn (ydai) exploiter",
+		Name:         "yearn exploiter 1",
+	}, d)
+	assert.True(t, d.NameChanged())
+	assert.False(t, d.IsEmpty())
+
+	assert.True(t, Diff(current, current).IsEmpty())
+
+	d = Diff(nil, current)
+	assert.Equal(t, current.Tags, d.AddedTags)
+	assert.Empty(t, d.RemovedTags)
+	assert.Equal(t, "", d.PreviousName)
+}
+
+func TestDiff_Banners(t *testing.T) {
+	previous := &AddressReport{
+		Tags:         []string{"heist"},
+		Reputation:   "Neutral",
+		Warnings:     []string{"reported for phishing"},
+		ContractName: "Token",
+	}
+	current := &AddressReport{
+		Tags:         []string{"heist"},
+		Reputation:   "Unsafe",
+		ContractName: "FakeToken",
+	}
+	d := Diff(previous, current)
+	assert.Equal(t, &ReportDiff{
+		RemovedWarnings:      []string{"reported for phishing"},
+		PreviousReputation:   "Neutral",
+		Reputation:           "Unsafe",
+		PreviousContractName: "Token",
+		ContractName:         "FakeToken",
+	}, d)
+	assert.False(t, d.IsEmpty())
+
+	// banner and contract changes alone are changes too
+	assert.False(t, Diff(&AddressReport{Tags: []string{"heist"}}, &AddressReport{Tags: []string{"heist"}, Warnings: []string{"scam"}}).IsEmpty())
+	assert.False(t, Diff(&AddressReport{}, &AddressReport{ContractName: "Token"}).IsEmpty())
+	assert.False(t, Diff(&AddressReport{Implementation: "0xabc"}, &AddressReport{Implementation: "0xdef"}).IsEmpty())
+}
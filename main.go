@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"fmt"
-	"forta-network/go-agent/domain"
 	"forta-network/go-agent/scanner"
 	"forta-network/go-agent/server"
 	"forta-network/go-agent/store"
@@ -13,10 +12,129 @@ import (
 	"net"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
+// loadRuleFile reads PARSER_RULES_FILE when set, otherwise the rules published for the chain
+func loadRuleFile(chainID int64) (*scanner.RuleFile, error) {
+	var rf *scanner.RuleFile
+	if filename := os.Getenv("PARSER_RULES_FILE"); filename != "" {
+		var err error
+		if rf, err = scanner.LoadRuleFile(filename); err != nil {
+			return nil, err
+		}
+	} else {
+		b, err := store.LoadParserRules(chainID)
+		if err != nil || b == nil {
+			return nil, err
+		}
+		if rf, err = scanner.ParseRuleFile(b); err != nil {
+			return nil, err
+		}
+	}
+	if err := rf.CheckChain(chainID); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// rateLimitFromEnv overrides the default explorer rate limit with SCANNER_RPS, SCANNER_BURST and SCANNER_MAX_RETRIES
+func rateLimitFromEnv() scanner.RateLimit {
+	rl := scanner.DefaultRateLimit
+	if v := os.Getenv("SCANNER_RPS"); v != "" {
+		rps, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			log.WithError(err).Fatalf("failed to parse SCANNER_RPS: %s", v)
+		}
+		rl.RequestsPerSecond = rps
+	}
+	if v := os.Getenv("SCANNER_BURST"); v != "" {
+		burst, err := strconv.Atoi(v)
+		if err != nil {
+			log.WithError(err).Fatalf("failed to parse SCANNER_BURST: %s", v)
+		}
+		rl.Burst = burst
+	}
+	if v := os.Getenv("SCANNER_MAX_RETRIES"); v != "" {
+		retries, err := strconv.Atoi(v)
+		if err != nil {
+			log.WithError(err).Fatalf("failed to parse SCANNER_MAX_RETRIES: %s", v)
+		}
+		rl.MaxRetries = retries
+	}
+	return rl
+}
+
+// clientConfigFromEnv overrides the default explorer http client with SCANNER_TIMEOUT and SCANNER_USER_AGENT
+func clientConfigFromEnv() scanner.ClientConfig {
+	cfg := scanner.DefaultClientConfig
+	if v := os.Getenv("SCANNER_TIMEOUT"); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			log.WithError(err).Fatalf("failed to parse SCANNER_TIMEOUT: %s", v)
+		}
+		cfg.Timeout = timeout
+	}
+	if v := os.Getenv("SCANNER_USER_AGENT"); v != "" {
+		cfg.UserAgent = v
+	}
+	return cfg
+}
+
+// addressFilterFromEnv builds the pre-scan address filter, overridable through
+// ADDRESS_ALLOWLIST, ADDRESS_MAX_FREQUENCY and ADDRESS_FREQUENCY_WINDOW
+func addressFilterFromEnv(chainID int64) *server.AddressFilter {
+	f := server.NewAddressFilter(chainID)
+	if v := os.Getenv("ADDRESS_ALLOWLIST"); v != "" {
+		f.Allow(strings.Split(v, ",")...)
+	}
+	if v := os.Getenv("ADDRESS_MAX_FREQUENCY"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			log.WithError(err).Fatalf("failed to parse address max frequency: %s", v)
+		}
+		f.MaxFrequency = n
+	}
+	if v := os.Getenv("ADDRESS_FREQUENCY_WINDOW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.WithError(err).Fatalf("failed to parse address frequency window: %s", v)
+		}
+		f.FrequencyWindow = d
+	}
+	return f
+}
+
+// consensusParsers builds the parsers of the other explorers in the comma-separated chain list
+func consensusParsers(chains string, chainID int64, secrets *store.Secrets) []scanner.Parser {
+	var result []scanner.Parser
+	for _, v := range strings.Split(chains, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			log.WithError(err).Fatalf("failed to parse consensus chain id: %s", v)
+		}
+		if id == chainID {
+			continue
+		}
+		p := scanner.NewParser(id)
+		if p == nil {
+			log.WithField("chainId", id).Warn("no explorer parser for consensus chain (skipping)")
+			continue
+		}
+		if key := secrets.ExplorerAPIKeys[v]; key != "" {
+			p = scanner.NewAPIParser(id, p, key)
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
 func main() {
 	port := os.Getenv("AGENT_GRPC_PORT")
 	if port == "" {
@@ -28,11 +146,23 @@ func main() {
 	}
 	grpcServer := grpc.NewServer()
 
+	storeCfg, err := store.ConfigFromEnv()
+	if err != nil {
+		log.WithError(err).Fatal("invalid label store config")
+	}
+
+	// only the DynamoDB store in AWS can't do without secrets
+	localStore := !storeCfg.NeedsSecrets()
+
 	// try up to 10 times in case there's some race condition
+	attempts := 10
+	if localStore {
+		attempts = 1
+	}
 	var secrets *store.Secrets
-	for i := 0; i < 10; i++ {
+	for i := 0; i < attempts; i++ {
 		secrets, err = store.LoadSecrets()
-		if err != nil {
+		if err != nil && i+1 < attempts {
 			log.WithError(err).Warnf("attempt %d, retrying (waiting 5s)", i)
 			time.Sleep(5 * time.Second)
 			continue
@@ -40,7 +170,12 @@ func main() {
 		break
 	}
 	if err != nil {
-		log.WithError(err).Fatal("failed to load secrets")
+		if localStore {
+			log.WithError(err).Warn("failed to load secrets (continuing without)")
+			secrets = &store.Secrets{}
+		} else {
+			log.WithError(err).Fatal("failed to load secrets")
+		}
 	}
 
 	chainIDEnv := os.Getenv("FORTA_CHAIN_ID")
@@ -49,17 +184,112 @@ func main() {
 		log.WithError(err).Fatalf("failed to parse chain id: %s", chainIDEnv)
 	}
 
-	db, err := store.NewLabelStore(context.Background(), chainID, os.Getenv("FORTA_BOT_ID"), secrets)
+	db, err := store.Open(context.Background(), storeCfg, chainID, os.Getenv("FORTA_BOT_ID"), secrets)
 	if err != nil {
 		log.WithError(err).Fatal("failed to init label store")
 	}
 
 	parser := scanner.NewParser(chainID)
+	if blockscoutURL := os.Getenv("BLOCKSCOUT_URL"); blockscoutURL != "" {
+		parser = scanner.NewBlockscoutParser(blockscoutURL)
+	}
+	rf, err := loadRuleFile(chainID)
+	if err != nil {
+		log.WithError(err).Warn("failed to load parser rules (using built-in parser)")
+	}
+	if rf != nil {
+		ruleParser, err := scanner.NewRuleParser(rf)
+		if err != nil {
+			log.WithError(err).Error("invalid parser rules (using built-in parser)")
+		} else {
+			log.WithField("rules", rf.ID).Info("using parser rules")
+			parser = ruleParser
+		}
+	}
+	if parser != nil {
+		if key := secrets.ExplorerAPIKeys[chainIDEnv]; key != "" {
+			log.Info("using explorer api")
+			parser = scanner.NewAPIParser(chainID, parser, key)
+		}
+	}
+	if parser != nil {
+		if others := consensusParsers(os.Getenv("CONSENSUS_CHAINS"), chainID, secrets); len(others) > 0 {
+			log.WithField("explorers", len(others)).Info("using cross-explorer consensus")
+			parser = scanner.NewConsensusParser(parser, others...)
+		}
+	}
+	if parser == nil {
+		log.WithField("chainId", chainID).Warn("no explorer parser for chain, addresses will not be scanned")
+	} else {
+		scanner.SetRateLimit(parser, rateLimitFromEnv())
+		scanner.ConfigureClient(clientConfigFromEnv())
+	}
+	var canaryInterval time.Duration
+	if v := os.Getenv("CANARY_INTERVAL"); v != "" {
+		canaryInterval, err = time.ParseDuration(v)
+		if err != nil {
+			log.WithError(err).Fatalf("failed to parse canary interval: %s", v)
+		}
+	}
+
+	var sweepInterval time.Duration
+	if v := os.Getenv("SWEEP_INTERVAL"); v != "" {
+		sweepInterval, err = time.ParseDuration(v)
+		if err != nil {
+			log.WithError(err).Fatalf("failed to parse sweep interval: %s", v)
+		}
+	}
+	var sweepBatchSize int
+	if v := os.Getenv("SWEEP_BATCH_SIZE"); v != "" {
+		sweepBatchSize, err = strconv.Atoi(v)
+		if err != nil {
+			log.WithError(err).Fatalf("failed to parse sweep batch size: %s", v)
+		}
+	}
+
+	var stateSize int
+	if v := os.Getenv("STATE_CACHE_SIZE"); v != "" {
+		stateSize, err = strconv.Atoi(v)
+		if err != nil {
+			log.WithError(err).Fatalf("failed to parse state cache size: %s", v)
+		}
+	}
+	var stateTTL time.Duration
+	if v := os.Getenv("STATE_CACHE_TTL"); v != "" {
+		stateTTL, err = time.ParseDuration(v)
+		if err != nil {
+			log.WithError(err).Fatalf("failed to parse state cache ttl: %s", v)
+		}
+	}
+
+	asyncScans := os.Getenv("ASYNC_SCANS") == "true"
+	var queueDepth, queueWorkers int
+	if v := os.Getenv("SCAN_QUEUE_DEPTH"); v != "" {
+		queueDepth, err = strconv.Atoi(v)
+		if err != nil {
+			log.WithError(err).Fatalf("failed to parse scan queue depth: %s", v)
+		}
+	}
+	if v := os.Getenv("SCAN_QUEUE_WORKERS"); v != "" {
+		queueWorkers, err = strconv.Atoi(v)
+		if err != nil {
+			log.WithError(err).Fatalf("failed to parse scan queue workers: %s", v)
+		}
+	}
+
 	protocol.RegisterAgentServer(grpcServer, &server.Agent{
-		State:  make(map[string]*domain.AddressReport),
-		Parser: parser,
-		Mux:    sync.Mutex{},
-		LStore: db,
+		State:          server.NewReportCache(stateSize, stateTTL),
+		Parser:         parser,
+		Mux:            sync.Mutex{},
+		LStore:         db,
+		Filter:         addressFilterFromEnv(chainID),
+		Canaries:       scanner.Canaries(chainID),
+		CanaryInterval: canaryInterval,
+		SweepInterval:  sweepInterval,
+		SweepBatchSize: sweepBatchSize,
+		AsyncScans:     asyncScans,
+		QueueDepth:     queueDepth,
+		QueueWorkers:   queueWorkers,
 	})
 
 	log.Info("started server")
@@ -0,0 +1,114 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"forta-network/go-agent/domain"
+)
+
+// apiURLs are the Etherscan-family api endpoints by chain id
+var apiURLs = map[int64]string{
+	1:     "https://api.etherscan.io/api",
+	10:    "https://api-optimistic.etherscan.io/api",
+	56:    "https://api.bscscan.com/api",
+	137:   "https://api.polygonscan.com/api",
+	250:   "https://api.ftmscan.com/api",
+	8453:  "https://api.basescan.org/api",
+	42161: "https://api.arbiscan.io/api",
+}
+
+type apiResponse struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Result  json.RawMessage `json:"result"`
+}
+
+type sourceCodeResult struct {
+	ContractName string `json:"contractname"`
+	// Proxy is "1" for proxy contracts, whose logic lives at Implementation
+	Proxy          string `json:"proxy"`
+	Implementation string `json:"implementation"`
+}
+
+// apiParser combines the labels scraped by the wrapped parser with the verified contract
+// name and proxy implementation from the explorer api
+type apiParser struct {
+	Parser
+	apiURL string
+	apiKey string
+}
+
+// NewAPIParser wraps the html parser with explorer api lookups; chains without a
+// known api are returned unchanged
+func NewAPIParser(chainID int64, html Parser, apiKey string) Parser {
+	u, ok := apiURLs[chainID]
+	if !ok || apiKey == "" {
+		return html
+	}
+	return &apiParser{Parser: html, apiURL: u, apiKey: apiKey}
+}
+
+// redactURL hides api keys so urls can be logged and returned in errors
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Query().Get("apikey") == "" {
+		return rawURL
+	}
+	q := u.Query()
+	q.Set("apikey", "redacted")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func (p *apiParser) call(ctx context.Context, params url.Values, result interface{}) error {
+	params.Set("apikey", p.apiKey)
+	body, err := getBody(ctx, fmt.Sprintf("%s?%s", p.apiURL, params.Encode()))
+	if err != nil {
+		return err
+	}
+	var resp apiResponse
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		return err
+	}
+	if resp.Status != "1" {
+		// rate limits were already retried by getBody, this is e.g. no data for the address
+		var msg string
+		_ = json.Unmarshal(resp.Result, &msg)
+		return fmt.Errorf("%w: %s %s", errAPINoResult, resp.Message, msg)
+	}
+	return json.Unmarshal(resp.Result, result)
+}
+
+var errAPINoResult = errors.New("explorer api returned no result")
+
+func (p *apiParser) ScanReport(ctx context.Context, address string) (*domain.AddressReport, error) {
+	rp, err := scanPages(ctx, p.Parser, address)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.addSourceCode(ctx, address, rp); err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+// addSourceCode adds the verified contract of the address and, for proxies, the implementation
+// to the report
+func (p *apiParser) addSourceCode(ctx context.Context, address string, rp *domain.AddressReport) error {
+	var sources []*sourceCodeResult
+	err := p.call(ctx, url.Values{"module": {"contract"}, "action": {"getsourcecode"}, "address": {address}}, &sources)
+	if err != nil && !errors.Is(err, errAPINoResult) {
+		return err
+	}
+	if len(sources) > 0 {
+		rp.ContractName = sources[0].ContractName
+		if sources[0].Proxy == "1" {
+			rp.Implementation = sources[0].Implementation
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIParser_ScanReport(t *testing.T) {
+	page, err := os.ReadFile("./testfiles/test.html")
+	assert.NoError(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api" {
+			_, _ = w.Write(page)
+			return
+		}
+		assert.Equal(t, "secret", r.URL.Query().Get("apikey"))
+		// the pro-only tokeninfo endpoint is never called
+		assert.Equal(t, "getsourcecode", r.URL.Query().Get("action"))
+		_, _ = w.Write([]byte(`{"status":"1","message":"OK","result":[{"ContractName":"TokenSale","Proxy":"1","Implementation":"0xABC"}]}`))
+	}))
+	defer srv.Close()
+
+	p := &apiParser{
+		Parser: &pathParser{patterns: []string{srv.URL + "/address/%s"}},
+		apiURL: srv.URL + "/api",
+		apiKey: "secret",
+	}
+	rp, err := Scan(context.Background(), p, "0xd4fd252d7d2c9479a8d616f510eac6243b5dddf9")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x: token sale", rp.Name)
+	assert.Equal(t, []string{"0x protocol", "token sale"}, rp.Tags)
+	assert.Equal(t, "tokensale", rp.ContractName)
+	assert.Equal(t, "0xabc", rp.Implementation)
+}
+
+func TestAPIParser_RetriesRateLimitResult(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api" {
+			return
+		}
+		if atomic.AddInt32(&calls, 1) < 3 {
+			_, _ = w.Write([]byte(`{"status":"0","message":"NOTOK","result":"Max rate limit reached"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"1","message":"OK","result":[{"ContractName":"TokenSale"}]}`))
+	}))
+	defer srv.Close()
+	p := &apiParser{
+		Parser: &pathParser{patterns: []string{srv.URL + "/address/%s"}},
+		apiURL: srv.URL + "/api",
+		apiKey: "secret",
+	}
+	SetRateLimit(p, RateLimit{MaxRetries: 3, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	rp, err := Scan(context.Background(), p, "0x1")
+	assert.NoError(t, err)
+	assert.Equal(t, "tokensale", rp.ContractName)
+	assert.Empty(t, rp.Implementation, "not a proxy")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestAPIParser_RateLimitRedactsKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api" {
+			return
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	p := &apiParser{
+		Parser: &pathParser{patterns: []string{srv.URL + "/address/%s"}},
+		apiURL: srv.URL + "/api",
+		apiKey: "secret",
+	}
+	SetRateLimit(p, RateLimit{})
+
+	_, err := Scan(context.Background(), p, "0x1")
+	assert.True(t, errors.Is(err, ErrRateLimited))
+	assert.False(t, strings.Contains(err.Error(), "secret"))
+}
+
+func TestNewAPIParser(t *testing.T) {
+	html := NewParser(1)
+	assert.Equal(t, html, NewAPIParser(1, html, ""))
+	assert.Equal(t, html, NewAPIParser(31337, html, "key"))
+	assert.IsType(t, &apiParser{}, NewAPIParser(1, html, "key"))
+}
@@ -0,0 +1,13 @@
+package scanner
+
+// arbitrumParser shares the current Etherscan layout served by etherscan.io
+type arbitrumParser struct {
+	mainnetParser
+}
+
+func (p *arbitrumParser) URLPatterns() []string {
+	return []string{
+		"https://arbiscan.io/token/%s",
+		"https://arbiscan.io/address/%s",
+	}
+}
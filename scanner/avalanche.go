@@ -0,0 +1,38 @@
+package scanner
+
+import (
+	"context"
+
+	"forta-network/go-agent/domain"
+)
+
+// routescanAPIURL is the Etherscan-compatible api of Routescan, which backs snowtrace.io; it
+// needs no api key
+const routescanAPIURL = "https://api.routescan.io/v2/network/mainnet/evm/43114/etherscan/api"
+
+// avalancheParser reads Avalanche addresses from the Routescan api only: snowtrace.io renders
+// its pages client-side, so they hold no markup to extract name tags from
+type avalancheParser struct {
+	api *apiParser
+}
+
+func newAvalancheParser() *avalancheParser {
+	return &avalancheParser{api: &apiParser{apiURL: routescanAPIURL}}
+}
+
+// URLPatterns names the explorer; the page is never fetched
+func (p *avalancheParser) URLPatterns() []string {
+	return []string{"https://snowtrace.io/address/%s"}
+}
+
+func (p *avalancheParser) ExtractName(body string) string { return "" }
+
+func (p *avalancheParser) ExtractTags(body string) []string { return nil }
+
+func (p *avalancheParser) ScanReport(ctx context.Context, address string) (*domain.AddressReport, error) {
+	rp := &domain.AddressReport{}
+	if err := p.api.addSourceCode(ctx, address, rp); err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
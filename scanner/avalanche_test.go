@@ -0,0 +1,30 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAvalancheParser_ScanReport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// only the api is requested, never the client-side rendered snowtrace page
+		assert.Equal(t, "/api", r.URL.Path)
+		assert.Equal(t, "getsourcecode", r.URL.Query().Get("action"))
+		_, _ = w.Write([]byte(`{"status":"1","message":"OK","result":[{"ContractName":"JoeRouter02"}]}`))
+	}))
+	defer srv.Close()
+
+	p := &avalancheParser{api: &apiParser{apiURL: srv.URL + "/api"}}
+	rp, err := Scan(context.Background(), p, "0x60ae616a2155ee3d9a68541ba4544862310933d4")
+	assert.NoError(t, err)
+	assert.Equal(t, "joerouter02", rp.ContractName)
+	assert.Empty(t, rp.Tags)
+
+	p = NewParser(43114).(*avalancheParser)
+	assert.Equal(t, "snowtrace.io", Source(p))
+	assert.Equal(t, []string{routescanAPIURL}, requestURLs(p))
+}
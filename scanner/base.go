@@ -0,0 +1,13 @@
+package scanner
+
+// baseParser shares the current Etherscan layout served by etherscan.io
+type baseParser struct {
+	mainnetParser
+}
+
+func (p *baseParser) URLPatterns() []string {
+	return []string{
+		"https://basescan.org/token/%s",
+		"https://basescan.org/address/%s",
+	}
+}
@@ -0,0 +1,104 @@
+package scanner
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+)
+
+// blockscoutURLs maps chain IDs without an Etherscan instance to their Blockscout explorer
+var blockscoutURLs = map[int64]string{
+	100:   "https://gnosis.blockscout.com",
+	42220: "https://explorer.celo.org/mainnet",
+}
+
+type blockscoutTag struct {
+	Name    string `json:"name"`
+	Slug    string `json:"slug"`
+	TagType string `json:"tagType"`
+}
+
+type blockscoutAddress struct {
+	Name  string `json:"name"`
+	Token *struct {
+		Name string `json:"name"`
+	} `json:"token"`
+	PublicTags []struct {
+		DisplayName string `json:"display_name"`
+		Label       string `json:"label"`
+	} `json:"public_tags"`
+	Metadata *struct {
+		Tags []blockscoutTag `json:"tags"`
+	} `json:"metadata"`
+}
+
+// blockscoutParser reads the Blockscout /api/v2/addresses JSON instead of scraping html
+type blockscoutParser struct {
+	baseURL string
+}
+
+func NewBlockscoutParser(baseURL string) Parser {
+	return &blockscoutParser{baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (p *blockscoutParser) decode(body string) *blockscoutAddress {
+	var addr blockscoutAddress
+	if err := json.Unmarshal([]byte(body), &addr); err != nil {
+		log.WithError(err).Warn("error decoding blockscout response (ignoring)")
+		return nil
+	}
+	return &addr
+}
+
+func (p *blockscoutParser) URLPatterns() []string {
+	return []string{
+		p.baseURL + "/api/v2/addresses/%s",
+	}
+}
+
+func (p *blockscoutParser) ExtractTags(body string) []string {
+	addr := p.decode(body)
+	if addr == nil {
+		return nil
+	}
+	var result []string
+	add := func(tag string) {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	for _, t := range addr.PublicTags {
+		add(t.DisplayName)
+	}
+	if addr.Metadata != nil {
+		for _, t := range addr.Metadata.Tags {
+			if t.TagType != "name" {
+				add(t.Name)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (p *blockscoutParser) ExtractName(body string) string {
+	addr := p.decode(body)
+	if addr == nil {
+		return ""
+	}
+	if addr.Metadata != nil {
+		for _, t := range addr.Metadata.Tags {
+			if t.TagType == "name" && strings.TrimSpace(t.Name) != "" {
+				return strings.TrimSpace(t.Name)
+			}
+		}
+	}
+	if addr.Token != nil && strings.TrimSpace(addr.Token.Name) != "" {
+		return strings.TrimSpace(addr.Token.Name)
+	}
+	return strings.TrimSpace(addr.Name)
+}
@@ -0,0 +1,37 @@
+package scanner
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBlockscoutParser_ExtractName(t *testing.T) {
+	scn := NewBlockscoutParser("https://gnosis.blockscout.com")
+	b, err := os.ReadFile("./testfiles/blockscout.json")
+	assert.NoError(t, err)
+	name := scn.ExtractName(strings.ToLower(string(b)))
+	assert.Equal(t, "hundred finance: exploiter", name)
+}
+
+func TestBlockscoutParser_ExtractTags(t *testing.T) {
+	scn := NewBlockscoutParser("https://gnosis.blockscout.com")
+	b, err := os.ReadFile("./testfiles/blockscout.json")
+	assert.NoError(t, err)
+	tags := scn.ExtractTags(strings.ToLower(string(b)))
+	assert.Equal(t, []string{"heist", "hundred finance exploiter", "phish / hack"}, tags)
+}
+
+func TestBlockscoutParser_ExtractName_TokenFallback(t *testing.T) {
+	scn := NewBlockscoutParser("https://explorer.celo.org/mainnet/")
+	name := scn.ExtractName(`{"name":"stabletokenproxy","token":{"name":"celo dollar"},"public_tags":[]}`)
+	assert.Equal(t, "celo dollar", name)
+	assert.Equal(t, []string{"https://explorer.celo.org/mainnet/api/v2/addresses/%s"}, scn.URLPatterns())
+}
+
+func TestBlockscoutParser_InvalidBody(t *testing.T) {
+	scn := NewBlockscoutParser("https://gnosis.blockscout.com")
+	assert.Empty(t, scn.ExtractName("<html>not json</html>"))
+	assert.Empty(t, scn.ExtractTags("<html>not json</html>"))
+}
@@ -1,12 +1,21 @@
 package scanner
 
-import (
-	"sort"
-	"strings"
-)
-
 type bscParser struct{}
 
+var bscRules = mustCompileRules(Rules{
+	Name: []Rule{
+		titleNameRule,
+	},
+	Tags: []Rule{
+		// red labels
+		{Selector: "span.u-label--danger"},
+		// grey labels
+		{Selector: `a[href^="/accounts/label/"]`, Attr: "href", Pattern: `^/accounts/label/([^/?#]+)`},
+	},
+	Reputation: []Rule{tokenRepRule},
+	Warnings:   []Rule{warningBannerRule},
+})
+
 func (p *bscParser) URLPatterns() []string {
 	return []string{
 		"https://www.bscscan.com/token/%s",
@@ -15,24 +24,21 @@ func (p *bscParser) URLPatterns() []string {
 }
 
 func (p *bscParser) ExtractTags(body string) []string {
-	// red labels
-	red := extractAllBetween(body, "<span class='u-label u-label--xs u-label--danger'>", "<")
-	// grey labels
-	grey := extractAllBetween(body, "/accounts/label/", "'")
-
-	result := append(red, grey...)
-	sort.Strings(result)
-	return result
+	return bscRules.extractTags(body)
 }
 
 func (p *bscParser) ExtractName(body string) string {
-	tokens := strings.Split(body, " | address 0x")
-	if len(tokens) > 1 {
-		htmlTokens := strings.Split(tokens[0], ">")
-		if len(htmlTokens) > 0 {
-			name := strings.Split(htmlTokens[len(htmlTokens)-1], "|")[0]
-			return strings.TrimSpace(name)
-		}
-	}
-	return ""
+	return bscRules.extractName(body)
+}
+
+func (p *bscParser) ExtractReputation(body string) string {
+	return bscRules.extractReputation(body)
+}
+
+func (p *bscParser) ExtractWarnings(body string) []string {
+	return bscRules.extractWarnings(body)
+}
+
+func (p *bscParser) extractPage(body string) *page {
+	return bscRules.extractPage(body)
 }
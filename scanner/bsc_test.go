@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"context"
 	"forta-network/go-agent/domain"
 	"github.com/stretchr/testify/assert"
 	"os"
@@ -25,6 +26,21 @@ func TestBSCScanner_ExtractTags(t *testing.T) {
 	assert.Equal(t, "phish / hack", tags[0])
 }
 
+func TestBSCScanner_ExtractBanners(t *testing.T) {
+	scn := &bscParser{}
+	b, err := os.ReadFile("./testfiles/bsctoken.html")
+	assert.NoError(t, err)
+	body := strings.ToLower(string(b))
+	assert.Equal(t, "suspicious", scn.ExtractReputation(body))
+	assert.Equal(t, []string{"this token has been flagged as suspicious"}, scn.ExtractWarnings(body))
+
+	b, err = os.ReadFile("./testfiles/bsc.html")
+	assert.NoError(t, err)
+	body = strings.ToLower(string(b))
+	assert.Empty(t, scn.ExtractReputation(body))
+	assert.Equal(t, []string{"this address is reported to be involved in a zero value token transfer phishing scam"}, scn.ExtractWarnings(body))
+}
+
 func TestBaseScanner_Scan(t *testing.T) {
 	tests := []*scanTest{
 		{
@@ -46,7 +62,10 @@ func TestBaseScanner_Scan(t *testing.T) {
 	}
 	for _, test := range tests {
 		scn := &bscParser{}
-		res := Scan(scn, test.Address)
+		res, err := Scan(context.Background(), scn, test.Address)
+		if !assert.NoError(t, err, test.Name) {
+			continue
+		}
 		if test.Expected == nil {
 			assert.Nil(t, res)
 			continue
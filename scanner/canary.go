@@ -0,0 +1,76 @@
+package scanner
+
+import (
+	"context"
+
+	"golang.org/x/exp/slices"
+)
+
+// Canary is a well-known address whose labels are not expected to change;
+// when a scan stops finding them the explorer markup has most likely drifted
+type Canary struct {
+	Address string
+	Name    string
+	Tags    []string
+}
+
+// Drift describes what a canary scan failed to extract
+type Drift struct {
+	Address      string   `json:"address"`
+	ExpectedName string   `json:"expectedName,omitempty"`
+	ActualName   string   `json:"actualName,omitempty"`
+	MissingTags  []string `json:"missingTags,omitempty"`
+}
+
+var canaries = map[int64][]*Canary{
+	1: {
+		{
+			Address: "0xdac17f958d2ee523a2206206994597c13d831ec7",
+			Name:    "tether: usdt stablecoin",
+			Tags:    []string{"stablecoin"},
+		},
+		{
+			Address: "0x14ec0cd2acee4ce37260b925f74648127a889a28",
+			Name:    "yearn (ydai) exploiter",
+			Tags:    []string{"heist"},
+		},
+	},
+	56: {
+		{
+			Address: "0x854c2e14bc43538454d8b0073a6fac2a684729ff",
+			Name:    "fake_phishing1014",
+			Tags:    []string{"phish / hack"},
+		},
+	},
+}
+
+// Canaries returns the canary addresses known for a chain
+func Canaries(chainID int64) []*Canary {
+	return canaries[chainID]
+}
+
+// CheckCanary scans the canary address and returns the drift, or nil if everything expected was found.
+// Fetch errors are returned as-is since a blocked page says nothing about the markup.
+func CheckCanary(ctx context.Context, p Parser, c *Canary) (*Drift, error) {
+	rp, err := Scan(ctx, p, c.Address)
+	if err != nil {
+		return nil, err
+	}
+	d := &Drift{Address: c.Address}
+	drifted := false
+	if c.Name != "" && rp.Name != c.Name {
+		d.ExpectedName = c.Name
+		d.ActualName = rp.Name
+		drifted = true
+	}
+	for _, t := range c.Tags {
+		if !slices.Contains(rp.Tags, t) {
+			d.MissingTags = append(d.MissingTags, t)
+			drifted = true
+		}
+	}
+	if !drifted {
+		return nil, nil
+	}
+	return d, nil
+}
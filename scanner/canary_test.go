@@ -0,0 +1,47 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testServerParser struct {
+	mainnetParser
+	url string
+}
+
+func (p *testServerParser) URLPatterns() []string {
+	return []string{p.url + "/address/%s"}
+}
+
+func TestCheckCanary(t *testing.T) {
+	page, err := os.ReadFile("./testfiles/yearnhack.html")
+	assert.NoError(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(page)
+	}))
+	defer srv.Close()
+	p := &testServerParser{url: srv.URL}
+
+	drift, err := CheckCanary(context.Background(), p, Canaries(1)[1])
+	assert.NoError(t, err)
+	assert.Nil(t, drift)
+
+	drift, err = CheckCanary(context.Background(), p, &Canary{
+		Address: "0x14ec0cd2acee4ce37260b925f74648127a889a28",
+		Name:    "yearn exploiter",
+		Tags:    []string{"heist", "phish / hack"},
+	})
+	assert.Equal(t, &Drift{
+		Address:      "0x14ec0cd2acee4ce37260b925f74648127a889a28",
+		ExpectedName: "yearn exploiter",
+		ActualName:   "yearn (ydai) exploiter",
+		MissingTags:  []string{"phish / hack"},
+	}, drift)
+	assert.NoError(t, err)
+}
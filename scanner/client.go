@@ -0,0 +1,83 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+// ClientConfig configures the http client used for every explorer request
+type ClientConfig struct {
+	// Timeout bounds a single request, including reading the body
+	Timeout   time.Duration
+	UserAgent string
+	// Transport defaults to http.DefaultTransport when nil
+	Transport http.RoundTripper
+}
+
+var DefaultClientConfig = ClientConfig{
+	Timeout:   30 * time.Second,
+	UserAgent: "Mozilla/5.0 (compatible; forta-etherscan-label-bot)",
+}
+
+var (
+	httpClient = newHTTPClient(DefaultClientConfig)
+	userAgent  = DefaultClientConfig.UserAgent
+)
+
+func newHTTPClient(cfg ClientConfig) *http.Client {
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: cfg.Transport,
+	}
+}
+
+// ConfigureClient replaces the explorer http client; it is meant to be called once at start-up
+func ConfigureClient(cfg ClientConfig) {
+	httpClient = newHTTPClient(cfg)
+	userAgent = cfg.UserAgent
+}
+
+func fetch(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		var ue *neturl.Error
+		if errors.As(err, &ue) {
+			ue.URL = redactURL(ue.URL)
+		}
+		return "", err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	body := strings.ToLower(string(b))
+	if err := checkResponse(redactURL(url), res, body); err != nil {
+		return "", err
+	}
+	return body, nil
+}
+
+// sleep waits for d or until the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
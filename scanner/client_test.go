@@ -0,0 +1,62 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetch_UserAgent(t *testing.T) {
+	var ua string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ua = r.UserAgent()
+	}))
+	defer srv.Close()
+
+	_, err := fetch(context.Background(), srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultClientConfig.UserAgent, ua)
+}
+
+func TestScan_Cancelled(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := Scan(ctx, &pathParser{patterns: []string{srv.URL + "/address/%s"}}, "0x1")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestConfigureClient_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ConfigureClient(ClientConfig{Timeout: 20 * time.Millisecond, UserAgent: "test"})
+	defer ConfigureClient(DefaultClientConfig)
+
+	_, err := fetch(context.Background(), srv.URL)
+	assert.Error(t, err)
+	assert.True(t, retryable(err))
+}
@@ -0,0 +1,110 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+
+	"forta-network/go-agent/domain"
+	"forta-network/go-agent/taxonomy"
+)
+
+// consensusParser scans an address on several explorers, attributing every tag to the
+// explorer it came from through its provenance
+type consensusParser struct {
+	Parser
+	others []Parser
+}
+
+// NewConsensusParser scans addresses on the primary explorer and on the others, merging
+// their reports. Only the primary explorer has to succeed; the report is marked partial when
+// another one fails.
+func NewConsensusParser(primary Parser, others ...Parser) Parser {
+	var ps []Parser
+	for _, o := range others {
+		if o != nil {
+			ps = append(ps, o)
+		}
+	}
+	if len(ps) == 0 {
+		return primary
+	}
+	return &consensusParser{Parser: primary, others: ps}
+}
+
+func (p *consensusParser) ScanReport(ctx context.Context, address string) (*domain.AddressReport, error) {
+	rp, err := Scan(ctx, p.Parser, address)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range p.others {
+		other, err := Scan(ctx, o, address)
+		if errors.Is(err, ErrNotFound) {
+			// the explorer has no page for the address, so no tags either
+			continue
+		}
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"entity": address,
+				"source": Source(o),
+			}).Warn("error scanning address on other explorer (partial report)")
+			rp.Partial = true
+			continue
+		}
+		rp.Merge(other)
+	}
+	rp.Conflicts = findConflicts(rp)
+	return rp, nil
+}
+
+// findConflicts flags malicious tag categories on one explorer that another explorer's
+// tags vouch against, e.g. phishing on one and exchange on the other
+func findConflicts(rp *domain.AddressReport) []domain.Conflict {
+	categories := make(map[string][]taxonomy.Category)
+	for tag, ps := range rp.Provenance {
+		c := taxonomy.Classify(tag)
+		for _, p := range ps {
+			if !slices.Contains(categories[p.ParserID], c) {
+				categories[p.ParserID] = append(categories[p.ParserID], c)
+			}
+		}
+	}
+	var result []domain.Conflict
+	for src, cs := range categories {
+		for other, ocs := range categories {
+			if src == other {
+				continue
+			}
+			for _, c := range cs {
+				for _, oc := range ocs {
+					if taxonomy.IsMalicious(c) && taxonomy.Conflicts(c, oc) {
+						result = append(result, domain.Conflict{
+							Source:        src,
+							Category:      string(c),
+							OtherSource:   other,
+							OtherCategory: string(oc),
+						})
+					}
+				}
+			}
+		}
+	}
+	// aids in testing
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		if a.OtherSource != b.OtherSource {
+			return a.OtherSource < b.OtherSource
+		}
+		return a.OtherCategory < b.OtherCategory
+	})
+	return result
+}
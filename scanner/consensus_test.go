@@ -0,0 +1,84 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"forta-network/go-agent/domain"
+)
+
+func tagServer(tags ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/address/0x1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprint(w, "<html><body>")
+		for _, t := range tags {
+			_, _ = fmt.Fprintf(w, `<span><i class="fa-hashtag"></i>%s</span>`, t)
+		}
+		_, _ = fmt.Fprint(w, "</body></html>")
+	}))
+}
+
+func TestConsensusParser_ScanReport(t *testing.T) {
+	primary := tagServer("Phish / Hack", "Heist")
+	defer primary.Close()
+	other := tagServer("Heist", "Binance: Hot Wallet")
+	defer other.Close()
+	missing := tagServer()
+	defer missing.Close()
+
+	p := NewConsensusParser(
+		&pathParser{patterns: []string{primary.URL + "/address/%s"}},
+		&pathParser{patterns: []string{other.URL + "/address/%s"}},
+		&pathParser{patterns: []string{missing.URL + "/missing/%s"}},
+	)
+	res, err := Scan(context.Background(), p, "0x1")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"phish / hack", "heist", "binance: hot wallet"}, res.Tags)
+
+	// tags are attributed to every explorer reporting them
+	assert.Len(t, res.Provenance["heist"], 2)
+	assert.Len(t, res.Provenance["phish / hack"], 1)
+
+	primaryID, otherID := hostOf(primary.URL), hostOf(other.URL)
+	assert.Equal(t, []domain.Conflict{
+		{Source: primaryID, Category: "exploit", OtherSource: otherID, OtherCategory: "exchange"},
+		{Source: primaryID, Category: "scam", OtherSource: otherID, OtherCategory: "exchange"},
+	}, res.Conflicts)
+	// an explorer without a page for the address has no tags for it
+	assert.False(t, res.Partial)
+}
+
+func TestConsensusParser_ScanReport_Partial(t *testing.T) {
+	primary := tagServer("Heist")
+	defer primary.Close()
+	blocked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer blocked.Close()
+
+	p := NewConsensusParser(
+		&pathParser{patterns: []string{primary.URL + "/address/%s"}},
+		&pathParser{patterns: []string{blocked.URL + "/address/%s"}},
+	)
+	res, err := Scan(context.Background(), p, "0x1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"heist"}, res.Tags)
+	assert.True(t, res.Partial)
+}
+
+func TestNewConsensusParser(t *testing.T) {
+	p := NewParser(1)
+	assert.Equal(t, p, NewConsensusParser(p, nil))
+
+	cp := NewConsensusParser(p, NewParser(56))
+	assert.Equal(t, "etherscan.io", Source(cp))
+	assert.Contains(t, requestURLs(cp), "https://www.bscscan.com/address/%s")
+}
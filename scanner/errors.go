@@ -0,0 +1,91 @@
+package scanner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrBlocked     = errors.New("blocked by explorer")
+	ErrRateLimited = errors.New("rate limited by explorer")
+	ErrNotFound    = errors.New("page not found")
+	ErrServerError = errors.New("explorer server error")
+)
+
+// FetchError is returned when an explorer page could not be used; it wraps one of the Err* kinds
+type FetchError struct {
+	URL        string
+	StatusCode int
+	// RetryAfter is the delay requested by the explorer, if any
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("%s: %s (status %d)", e.URL, e.Err, e.StatusCode)
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// challengeMarkers are found on Cloudflare interstitials, which may be served with any status.
+// Regular explorer pages also load /cdn-cgi/challenge-platform scripts, so that is not a marker.
+var challengeMarkers = []string{
+	"<title>just a moment...</title>",
+	"<title>attention required! | cloudflare</title>",
+	"cf-browser-verification",
+}
+
+func isChallenge(res *http.Response, body string) bool {
+	if res.Header.Get("cf-mitigated") == "challenge" {
+		return true
+	}
+	for _, m := range challengeMarkers {
+		if strings.Contains(body, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAPIRateLimit recognizes the Etherscan-family api rate limit, which is answered with
+// status 200 and a "max rate limit reached" result
+func isAPIRateLimit(res *http.Response, body string) bool {
+	if res.StatusCode != http.StatusOK || !strings.HasPrefix(strings.TrimSpace(body), "{") {
+		return false
+	}
+	var resp apiResponse
+	if err := json.Unmarshal([]byte(body), &resp); err != nil || resp.Status != "0" {
+		return false
+	}
+	var msg string
+	_ = json.Unmarshal(resp.Result, &msg)
+	return strings.Contains(msg, "rate limit")
+}
+
+// checkResponse classifies unusable responses; body is expected to be lowercased
+func checkResponse(url string, res *http.Response, body string) error {
+	var kind error
+	switch {
+	case isChallenge(res, body):
+		kind = ErrBlocked
+	case res.StatusCode == http.StatusTooManyRequests, isAPIRateLimit(res, body):
+		kind = ErrRateLimited
+	case res.StatusCode == http.StatusNotFound:
+		kind = ErrNotFound
+	case res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusUnauthorized:
+		kind = ErrBlocked
+	case res.StatusCode >= 500:
+		kind = ErrServerError
+	case res.StatusCode >= 400:
+		kind = fmt.Errorf("unexpected response")
+	default:
+		return nil
+	}
+	return &FetchError{URL: url, StatusCode: res.StatusCode, RetryAfter: parseRetryAfter(res), Err: kind}
+}
@@ -0,0 +1,135 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEtherscanFamily runs the explorers that reuse the etherscan or bscscan markup against
+// pages captured from the explorer whose layout they share; TestExplorerPages checks the layout
+// each explorer actually serves
+func TestEtherscanFamily(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Parser  Parser
+		Host    string
+		Fixture string
+		TagName string
+		Tags    []string
+	}{
+		{"optimism", &optimismParser{}, "optimistic.etherscan.io", "yearnhack.html", "yearn (ydai) exploiter", []string{"blocked", "heist"}},
+		{"arbitrum", &arbitrumParser{}, "arbiscan.io", "yearnhack.html", "yearn (ydai) exploiter", []string{"blocked", "heist"}},
+		{"base", &baseParser{}, "basescan.org", "yearnhack.html", "yearn (ydai) exploiter", []string{"blocked", "heist"}},
+		{"polygon", &polygonParser{}, "polygonscan.com", "bsc.html", "fake_phishing1014", []string{"phish / hack"}},
+		{"fantom", &fantomParser{}, "ftmscan.com", "bsc.html", "fake_phishing1014", []string{"phish / hack"}},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			for _, p := range test.Parser.URLPatterns() {
+				assert.Equal(t, test.Host, hostOf(p))
+			}
+			b, err := os.ReadFile("./testfiles/" + test.Fixture)
+			assert.NoError(t, err)
+			body := strings.ToLower(string(b))
+			assert.Equal(t, test.TagName, test.Parser.ExtractName(body))
+			assert.Equal(t, test.Tags, test.Parser.ExtractTags(body))
+		})
+	}
+}
+
+// capture fetches explorer pages into testfiles/pages before TestExplorerPages reads them, e.g.
+//
+//	go test ./scanner -run TestExplorerPages -capture 42161:0x...,10:0x...
+//
+// The labels extracted from each page are written next to it for review with the page.
+var capture = flag.String("capture", "", "comma-separated chainID:address pages to capture")
+
+// capturedPage is a page captured from an explorer, relative to testfiles, and what it is
+// expected to yield
+type capturedPage struct {
+	Page string   `json:"page"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// pageChains are the chains whose explorer pages are scraped
+var pageChains = []int64{1, 10, 56, 137, 250, 8453, 42161}
+
+func capturePage(t *testing.T, spec string) {
+	id, address, ok := strings.Cut(spec, ":")
+	chainID, err := strconv.ParseInt(id, 10, 64)
+	if !ok || err != nil {
+		t.Fatalf("invalid page %q, expected chainID:address", spec)
+	}
+	p := NewParser(chainID)
+	if p == nil {
+		t.Fatalf("no parser for chain %d", chainID)
+	}
+	var url string
+	for _, up := range p.URLPatterns() {
+		if strings.Contains(up, "/address/") {
+			url = fmt.Sprintf(up, address)
+		}
+	}
+	body, err := getBody(context.Background(), url)
+	if err != nil {
+		t.Fatalf("failed to capture %s: %v", url, err)
+	}
+	dir := filepath.Join("testfiles", "pages", Source(p))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := json.MarshalIndent(capturedPage{
+		Page: filepath.Join("pages", Source(p), address+".html"),
+		Name: p.ExtractName(body),
+		Tags: p.ExtractTags(body),
+	}, "", "  ")
+	if err := os.WriteFile(filepath.Join(dir, address+".html"), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, address+".json"), expected, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestExplorerPages runs each explorer's parser against the pages captured from that explorer,
+// so that a layout change shows up when the pages are re-captured
+func TestExplorerPages(t *testing.T) {
+	if *capture != "" {
+		for _, spec := range strings.Split(*capture, ",") {
+			capturePage(t, spec)
+		}
+	}
+	for _, chainID := range pageChains {
+		p := NewParser(chainID)
+		t.Run(Source(p), func(t *testing.T) {
+			pages, _ := filepath.Glob(filepath.Join("testfiles", "pages", Source(p), "*.json"))
+			if len(pages) == 0 {
+				t.Skipf("no page captured from %s: go test ./scanner -run TestExplorerPages -capture %d:<labelled address>", Source(p), chainID)
+			}
+			for _, page := range pages {
+				b, err := os.ReadFile(page)
+				assert.NoError(t, err)
+				var expected capturedPage
+				assert.NoError(t, json.Unmarshal(b, &expected))
+				body, err := os.ReadFile(filepath.Join("testfiles", expected.Page))
+				if !assert.NoError(t, err) {
+					continue
+				}
+				// pages are lowercased when fetched
+				html := strings.ToLower(string(body))
+				assert.Equal(t, expected.Name, p.ExtractName(html), expected.Page)
+				assert.Equal(t, expected.Tags, p.ExtractTags(html), expected.Page)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package scanner
+
+// fantomParser shares the legacy Etherscan layout served by bscscan
+type fantomParser struct {
+	bscParser
+}
+
+func (p *fantomParser) URLPatterns() []string {
+	return []string{
+		"https://ftmscan.com/token/%s",
+		"https://ftmscan.com/address/%s",
+	}
+}
@@ -1,12 +1,23 @@
 package scanner
 
-import (
-	"sort"
-	"strings"
-)
-
 type mainnetParser struct{}
 
+var mainnetRules = mustCompileRules(Rules{
+	Name: []Rule{
+		titleNameRule,
+		// public name tag badge in the page header
+		{Selector: `span[title^="public name tag"]`},
+	},
+	Tags: []Rule{
+		// hashtag labels, both plain links and red warning badges
+		{Selector: "a:haschild(i.fa-hashtag), span:haschild(i.fa-hashtag)"},
+		// red warning labels without a hashtag icon, except the token reputation badge
+		{Selector: `span.badge.bg-danger:not(a[href^="/tokens/label/"] span)`},
+	},
+	Reputation: []Rule{tokenRepRule},
+	Warnings:   []Rule{warningBannerRule},
+})
+
 func (p *mainnetParser) URLPatterns() []string {
 	return []string{
 		"https://etherscan.io/token/%s",
@@ -15,31 +26,21 @@ func (p *mainnetParser) URLPatterns() []string {
 }
 
 func (p *mainnetParser) ExtractTags(body string) []string {
-	var result []string
-	tokens := strings.Split(body, "<i class='far fa-hashtag'></i>")
-	if len(tokens) > 1 {
-		for i := 1; i < len(tokens); i++ {
-			t := strings.Replace(tokens[i], "<span class='hash-tag text-truncate'>", "", 1)
-			r := strings.TrimSpace(strings.Split(t, "<")[0])
-			if r != "" {
-				result = append(result, r)
-			}
-		}
-	}
-	// aids in testing
-	sort.Strings(result)
-	return result
+	return mainnetRules.extractTags(body)
 }
 
 func (p *mainnetParser) ExtractName(body string) string {
-	tokens := strings.Split(body, " | address 0x")
-
-	if len(tokens) > 1 {
-		htmlTokens := strings.Split(tokens[0], ">")
-		if len(htmlTokens) > 0 {
-			name := strings.Split(htmlTokens[len(htmlTokens)-1], "|")[0]
-			return strings.TrimSpace(name)
-		}
-	}
-	return ""
+	return mainnetRules.extractName(body)
+}
+
+func (p *mainnetParser) ExtractReputation(body string) string {
+	return mainnetRules.extractReputation(body)
+}
+
+func (p *mainnetParser) ExtractWarnings(body string) []string {
+	return mainnetRules.extractWarnings(body)
+}
+
+func (p *mainnetParser) extractPage(body string) *page {
+	return mainnetRules.extractPage(body)
 }
@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"context"
 	"forta-network/go-agent/domain"
 	"github.com/stretchr/testify/assert"
 	"os"
@@ -30,6 +31,27 @@ func TestMainnetParser_ExtractTags(t *testing.T) {
 	assert.Len(t, tags, 2)
 }
 
+func TestMainnetParser_ExtractBanners(t *testing.T) {
+	scn := &mainnetParser{}
+	b, err := os.ReadFile("./testfiles/test.html")
+	assert.NoError(t, err)
+	assert.Empty(t, scn.ExtractWarnings(strings.ToLower(string(b))))
+
+	b, err = os.ReadFile("./testfiles/token.html")
+	assert.NoError(t, err)
+	body := strings.ToLower(string(b))
+	assert.Equal(t, "unsafe", scn.ExtractReputation(body))
+	assert.Equal(t, []string{"warning! there are reports that this address was used in a phishing scam"}, scn.ExtractWarnings(body))
+	// the reputation badge is no tag
+	assert.Empty(t, scn.ExtractTags(body))
+
+	b, err = os.ReadFile("./testfiles/yearnhack.html")
+	assert.NoError(t, err)
+	body = strings.ToLower(string(b))
+	assert.Empty(t, scn.ExtractReputation(body))
+	assert.Equal(t, []string{"this address is related to a sequence of exploits targeting the yearn dai v1 vault as reported here: https://twitter.com/iearnfinance/status/1357451290561937408"}, scn.ExtractWarnings(body))
+}
+
 func TestMainnetParser_Scan(t *testing.T) {
 	tests := []*scanTest{
 		{
@@ -59,7 +81,10 @@ func TestMainnetParser_Scan(t *testing.T) {
 	}
 	for _, test := range tests {
 		scn := &mainnetParser{}
-		res := Scan(scn, test.Address)
+		res, err := Scan(context.Background(), scn, test.Address)
+		if !assert.NoError(t, err, test.Name) {
+			continue
+		}
 		if test.Expected == nil {
 			assert.Nil(t, res)
 			continue
@@ -0,0 +1,13 @@
+package scanner
+
+// optimismParser shares the current Etherscan layout served by etherscan.io
+type optimismParser struct {
+	mainnetParser
+}
+
+func (p *optimismParser) URLPatterns() []string {
+	return []string{
+		"https://optimistic.etherscan.io/token/%s",
+		"https://optimistic.etherscan.io/address/%s",
+	}
+}
@@ -0,0 +1,13 @@
+package scanner
+
+// polygonParser shares the legacy Etherscan layout served by bscscan
+type polygonParser struct {
+	bscParser
+}
+
+func (p *polygonParser) URLPatterns() []string {
+	return []string{
+		"https://polygonscan.com/token/%s",
+		"https://polygonscan.com/address/%s",
+	}
+}
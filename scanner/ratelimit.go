@@ -0,0 +1,183 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit throttles the requests made to one explorer host
+type RateLimit struct {
+	RequestsPerSecond float64
+	Burst             int
+	// MaxRetries is how many times a rate-limited or failed request is retried
+	MaxRetries  int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// DefaultRateLimit stays under the public (keyless) explorer limits
+var DefaultRateLimit = RateLimit{
+	RequestsPerSecond: 2,
+	Burst:             4,
+	MaxRetries:        3,
+	BaseBackoff:       2 * time.Second,
+	MaxBackoff:        time.Minute,
+}
+
+// hostLimiter is a token bucket shared by every request to the same host
+type hostLimiter struct {
+	mu     sync.Mutex
+	config RateLimit
+	tokens float64
+	last   time.Time
+	// pausedUntil holds back every request to the host after a rate-limit response
+	pausedUntil time.Time
+}
+
+var limiters = struct {
+	sync.Mutex
+	m map[string]*hostLimiter
+}{m: make(map[string]*hostLimiter)}
+
+func newHostLimiter(rl RateLimit) *hostLimiter {
+	return &hostLimiter{
+		config: rl,
+		tokens: float64(rl.Burst),
+		last:   time.Now(),
+	}
+}
+
+func hostOf(rawURL string) string {
+	// url patterns don't parse with their "%s" placeholder in place
+	u, err := url.Parse(strings.ReplaceAll(rawURL, "%s", "x"))
+	if err != nil {
+		return rawURL
+	}
+	return u.Host
+}
+
+// requestURLs returns the url patterns of every page the parser requests
+func requestURLs(p Parser) []string {
+	switch pp := p.(type) {
+	case *apiParser:
+		return append(requestURLs(pp.Parser), pp.apiURL)
+	case *avalancheParser:
+		return []string{pp.api.apiURL}
+	case *consensusParser:
+		urls := requestURLs(pp.Parser)
+		for _, o := range pp.others {
+			urls = append(urls, requestURLs(o)...)
+		}
+		return urls
+	}
+	return p.URLPatterns()
+}
+
+// SetRateLimit configures the limiter for every host the parser requests
+func SetRateLimit(p Parser, rl RateLimit) {
+	limiters.Lock()
+	defer limiters.Unlock()
+	for _, u := range requestURLs(p) {
+		limiters.m[hostOf(u)] = newHostLimiter(rl)
+	}
+}
+
+func limiterFor(rawURL string) *hostLimiter {
+	host := hostOf(rawURL)
+	limiters.Lock()
+	defer limiters.Unlock()
+	l, ok := limiters.m[host]
+	if !ok {
+		l = newHostLimiter(DefaultRateLimit)
+		limiters.m[host] = l
+	}
+	return l
+}
+
+// reserve takes a token and returns how long the caller has to wait before using it
+func (l *hostLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if l.config.RequestsPerSecond <= 0 {
+		return l.pausedUntil.Sub(now)
+	}
+	l.tokens += now.Sub(l.last).Seconds() * l.config.RequestsPerSecond
+	if burst := float64(l.config.Burst); l.tokens > burst {
+		l.tokens = burst
+	}
+	l.last = now
+	l.tokens--
+
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.config.RequestsPerSecond * float64(time.Second))
+	}
+	if paused := l.pausedUntil.Sub(now); paused > wait {
+		wait = paused
+	}
+	return wait
+}
+
+func (l *hostLimiter) wait(ctx context.Context) error {
+	if d := l.reserve(); d > 0 {
+		return sleep(ctx, d)
+	}
+	return nil
+}
+
+// pause holds back every request to the host for d
+func (l *hostLimiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// backoff returns the delay before retry attempt+1, preferring the server's Retry-After
+func (l *hostLimiter) backoff(attempt int, err error) time.Duration {
+	var fe *FetchError
+	if errors.As(err, &fe) && fe.RetryAfter > 0 {
+		return fe.RetryAfter
+	}
+	d := l.config.BaseBackoff << attempt
+	if d <= 0 || d > l.config.MaxBackoff {
+		d = l.config.MaxBackoff
+	}
+	// jitter so that workers don't retry in lockstep
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryable reports whether another attempt may succeed; cancellation of the caller's
+// context is checked separately since client timeouts also surface as deadline errors
+func retryable(err error) bool {
+	if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrServerError) {
+		return true
+	}
+	// transport errors (timeouts, resets) are worth another try too
+	var fe *FetchError
+	return !errors.As(err, &fe)
+}
+
+// parseRetryAfter reads a Retry-After header in either seconds or http-date form
+func parseRetryAfter(res *http.Response) time.Duration {
+	v := res.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostLimiter_Reserve(t *testing.T) {
+	l := newHostLimiter(RateLimit{RequestsPerSecond: 10, Burst: 2})
+	assert.Zero(t, l.reserve())
+	assert.Zero(t, l.reserve())
+	wait := l.reserve()
+	assert.Greater(t, wait, 50*time.Millisecond)
+	assert.LessOrEqual(t, wait, 100*time.Millisecond)
+
+	l.pause(time.Second)
+	assert.Greater(t, l.reserve(), 900*time.Millisecond)
+}
+
+func TestHostLimiter_Backoff(t *testing.T) {
+	l := newHostLimiter(RateLimit{BaseBackoff: time.Second, MaxBackoff: 4 * time.Second})
+	assert.Equal(t, 7*time.Second, l.backoff(0, &FetchError{Err: ErrRateLimited, RetryAfter: 7 * time.Second}))
+	for attempt, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second, 4 * time.Second} {
+		d := l.backoff(attempt, &FetchError{Err: ErrServerError})
+		assert.GreaterOrEqual(t, d, max/2)
+		assert.LessOrEqual(t, d, max)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	res := &http.Response{Header: http.Header{}}
+	assert.Zero(t, parseRetryAfter(res))
+	res.Header.Set("Retry-After", "3")
+	assert.Equal(t, 3*time.Second, parseRetryAfter(res))
+	res.Header.Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.Greater(t, parseRetryAfter(res), 50*time.Second)
+}
+
+func TestGetBody_RetriesRateLimit(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("OK"))
+	}))
+	defer srv.Close()
+	SetRateLimit(&pathParser{patterns: []string{srv.URL}}, RateLimit{MaxRetries: 2, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	body, err := getBody(context.Background(), srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", body)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestSetRateLimit(t *testing.T) {
+	rl := RateLimit{RequestsPerSecond: 7, Burst: 1}
+	SetRateLimit(&optimismParser{}, rl)
+	assert.Equal(t, rl, limiterFor("https://optimistic.etherscan.io/address/0x1").config)
+}
@@ -0,0 +1,90 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// RuleFile describes how to scrape one explorer, so extraction can be hot-fixed
+// by shipping a new file instead of a new release
+type RuleFile struct {
+	ID          string   `json:"id"`
+	ChainID     int64    `json:"chainId"`
+	URLPatterns []string `json:"urlPatterns"`
+	Rules
+}
+
+// CheckChain rejects a rule file written for another chain, which would otherwise replace the
+// chain's parser with another explorer's
+func (rf *RuleFile) CheckChain(chainID int64) error {
+	if rf.ChainID != chainID {
+		return fmt.Errorf("rule file %s is for chain %d, not %d", rf.ID, rf.ChainID, chainID)
+	}
+	return nil
+}
+
+type ruleParser struct {
+	id          string
+	urlPatterns []string
+	rules       *ruleSet
+}
+
+func (p *ruleParser) ID() string {
+	return p.id
+}
+
+func (p *ruleParser) URLPatterns() []string {
+	return p.urlPatterns
+}
+
+func (p *ruleParser) ExtractTags(body string) []string {
+	return p.rules.extractTags(body)
+}
+
+func (p *ruleParser) ExtractName(body string) string {
+	return p.rules.extractName(body)
+}
+
+func (p *ruleParser) ExtractReputation(body string) string {
+	return p.rules.extractReputation(body)
+}
+
+func (p *ruleParser) ExtractWarnings(body string) []string {
+	return p.rules.extractWarnings(body)
+}
+
+func (p *ruleParser) extractPage(body string) *page {
+	return p.rules.extractPage(body)
+}
+
+func NewRuleParser(rf *RuleFile) (Parser, error) {
+	if len(rf.URLPatterns) == 0 {
+		return nil, fmt.Errorf("rule file %s has no url patterns", rf.ID)
+	}
+	rules, err := compileRules(rf.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("rule file %s: %w", rf.ID, err)
+	}
+	return &ruleParser{
+		id:          rf.ID,
+		urlPatterns: rf.URLPatterns,
+		rules:       rules,
+	}, nil
+}
+
+func ParseRuleFile(b []byte) (*RuleFile, error) {
+	var rf RuleFile
+	if err := json.Unmarshal(b, &rf); err != nil {
+		return nil, err
+	}
+	return &rf, nil
+}
+
+func LoadRuleFile(filename string) (*RuleFile, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRuleFile(b)
+}
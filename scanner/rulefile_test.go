@@ -0,0 +1,61 @@
+package scanner
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRuleParser_MatchesBuiltin(t *testing.T) {
+	tests := []struct {
+		RuleFile string
+		Builtin  Parser
+		Fixtures []string
+	}{
+		{"./rules/1.json", &mainnetParser{}, []string{"test.html", "yearnhack.html", "token.html"}},
+		{"./rules/56.json", &bscParser{}, []string{"bsc.html", "bsctoken.html"}},
+	}
+	for _, test := range tests {
+		rf, err := LoadRuleFile(test.RuleFile)
+		assert.NoError(t, err)
+		p, err := NewRuleParser(rf)
+		assert.NoError(t, err)
+		assert.Equal(t, test.Builtin.URLPatterns(), p.URLPatterns(), test.RuleFile)
+		assert.Equal(t, NewParser(rf.ChainID).URLPatterns(), p.URLPatterns(), test.RuleFile)
+
+		for _, f := range test.Fixtures {
+			b, err := os.ReadFile("./testfiles/" + f)
+			assert.NoError(t, err)
+			body := strings.ToLower(string(b))
+			assert.Equal(t, test.Builtin.ExtractName(body), p.ExtractName(body), f)
+			assert.Equal(t, test.Builtin.ExtractTags(body), p.ExtractTags(body), f)
+			bp, builtin := p.(BannerParser), test.Builtin.(BannerParser)
+			assert.Equal(t, builtin.ExtractReputation(body), bp.ExtractReputation(body), f)
+			assert.Equal(t, builtin.ExtractWarnings(body), bp.ExtractWarnings(body), f)
+		}
+	}
+}
+
+func TestNewRuleParser_Invalid(t *testing.T) {
+	_, err := NewRuleParser(&RuleFile{ID: "empty"})
+	assert.Error(t, err)
+
+	rf, err := ParseRuleFile([]byte(`{"id":"bad","urlPatterns":["https://example.com/%s"],"tags":[{"selector":"a[href"}]}`))
+	assert.NoError(t, err)
+	_, err = NewRuleParser(rf)
+	assert.Error(t, err)
+
+	rf, err = ParseRuleFile([]byte(`{"id":"bad","urlPatterns":["https://example.com/%s"],"name":[{"selector":"title","pattern":"("}]}`))
+	assert.NoError(t, err)
+	_, err = NewRuleParser(rf)
+	assert.Error(t, err)
+}
+
+func TestRuleFile_CheckChain(t *testing.T) {
+	rf, err := LoadRuleFile("./rules/56.json")
+	assert.NoError(t, err)
+	assert.NoError(t, rf.CheckChain(56))
+	assert.Error(t, rf.CheckChain(1))
+	assert.Error(t, (&RuleFile{ID: "no-chain"}).CheckChain(1))
+}
@@ -0,0 +1,222 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+	"golang.org/x/net/html"
+)
+
+// Rule extracts values from the nodes matching Selector, reading Attr instead of the
+// node text when set. When Pattern is set, values that don't match it are dropped and
+// the first capture group (or the whole match) is kept.
+type Rule struct {
+	Selector string `json:"selector"`
+	Attr     string `json:"attr,omitempty"`
+	Pattern  string `json:"pattern,omitempty"`
+}
+
+type compiledRule struct {
+	sel  cascadia.SelectorGroup
+	attr string
+	re   *regexp.Regexp
+}
+
+func compileRule(r Rule) (*compiledRule, error) {
+	sel, err := compileSelector(r.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("selector %q: %w", r.Selector, err)
+	}
+	cr := &compiledRule{sel: sel, attr: strings.ToLower(r.Attr)}
+	if r.Pattern != "" {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", r.Pattern, err)
+		}
+		cr.re = re
+	}
+	return cr, nil
+}
+
+func (r *compiledRule) extract(doc *html.Node) []string {
+	var result []string
+	for _, n := range cascadia.QueryAll(doc, r.sel) {
+		var v string
+		if r.attr != "" {
+			v, _ = getAttr(n, r.attr)
+		} else {
+			v = nodeText(n)
+		}
+		if r.re != nil {
+			m := r.re.FindStringSubmatch(v)
+			if m == nil {
+				continue
+			}
+			v = m[0]
+			if len(m) > 1 {
+				v = m[1]
+			}
+		}
+		v = strings.TrimSpace(v)
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// Rules groups the extraction rules for one page layout. The first name and reputation
+// rule with a result wins, the results of all tag and warning rules are combined.
+type Rules struct {
+	Name       []Rule `json:"name"`
+	Tags       []Rule `json:"tags"`
+	Reputation []Rule `json:"reputation,omitempty"`
+	Warnings   []Rule `json:"warnings,omitempty"`
+}
+
+type ruleSet struct {
+	name       []*compiledRule
+	tags       []*compiledRule
+	reputation []*compiledRule
+	warnings   []*compiledRule
+}
+
+func compileRuleList(rules []Rule) ([]*compiledRule, error) {
+	var result []*compiledRule
+	for _, r := range rules {
+		cr, err := compileRule(r)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, cr)
+	}
+	return result, nil
+}
+
+func compileRules(r Rules) (*ruleSet, error) {
+	var err error
+	rs := &ruleSet{}
+	if rs.name, err = compileRuleList(r.Name); err != nil {
+		return nil, err
+	}
+	if rs.tags, err = compileRuleList(r.Tags); err != nil {
+		return nil, err
+	}
+	if rs.reputation, err = compileRuleList(r.Reputation); err != nil {
+		return nil, err
+	}
+	if rs.warnings, err = compileRuleList(r.Warnings); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+func mustCompileRules(r Rules) *ruleSet {
+	rs, err := compileRules(r)
+	if err != nil {
+		panic(err)
+	}
+	return rs
+}
+
+func parseDocument(body string) *html.Node {
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		log.WithError(err).Warn("error parsing page (ignoring)")
+		return nil
+	}
+	return doc
+}
+
+func first(rules []*compiledRule, doc *html.Node) string {
+	if doc == nil {
+		return ""
+	}
+	for _, r := range rules {
+		if vals := r.extract(doc); len(vals) > 0 {
+			return vals[0]
+		}
+	}
+	return ""
+}
+
+func all(rules []*compiledRule, doc *html.Node) []string {
+	if doc == nil {
+		return nil
+	}
+	var result []string
+	for _, r := range rules {
+		for _, t := range r.extract(doc) {
+			if !slices.Contains(result, t) {
+				result = append(result, t)
+			}
+		}
+	}
+	// aids in testing
+	sort.Strings(result)
+	return result
+}
+
+// page is everything a rule set extracts from one page
+type page struct {
+	Name       string
+	Tags       []string
+	Reputation string
+	Warnings   []string
+}
+
+// pageExtractor is implemented by rule-driven parsers, which extract the whole page from a
+// single parse instead of one per Extract call
+type pageExtractor interface {
+	extractPage(body string) *page
+}
+
+func (rs *ruleSet) extractPage(body string) *page {
+	doc := parseDocument(body)
+	return &page{
+		Name:       first(rs.name, doc),
+		Tags:       all(rs.tags, doc),
+		Reputation: first(rs.reputation, doc),
+		Warnings:   all(rs.warnings, doc),
+	}
+}
+
+func (rs *ruleSet) extractName(body string) string {
+	return first(rs.name, parseDocument(body))
+}
+
+func (rs *ruleSet) extractTags(body string) []string {
+	return all(rs.tags, parseDocument(body))
+}
+
+func (rs *ruleSet) extractReputation(body string) string {
+	return first(rs.reputation, parseDocument(body))
+}
+
+func (rs *ruleSet) extractWarnings(body string) []string {
+	return all(rs.warnings, parseDocument(body))
+}
+
+// titleNameRule reads the name from "<name> | Address 0x... | <explorer>" page titles
+var titleNameRule = Rule{
+	Selector: "title",
+	Pattern:  `(?i)^([^|]*?)\s*\|\s*address 0x`,
+}
+
+// tokenRepRule reads the token reputation from the "Token Rep" row of token pages
+var tokenRepRule = Rule{
+	Selector: "div",
+	Pattern:  `(?i)^token rep(?:utation)?\s*:?\s*(ok|neutral|suspicious|unsafe)\b`,
+}
+
+// warningBannerRule reads the first sentence of red alert banners, without the dismiss button.
+// Yellow alerts are left out since they are also used for compiler warnings on contract pages.
+var warningBannerRule = Rule{
+	Selector: "div.alert-danger",
+	Pattern:  `^[×\s]*(.+?)(?:\.(?:\s|$)|$)`,
+}
@@ -1,9 +1,9 @@
 package scanner
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
 	"time"
 
@@ -17,65 +17,150 @@ type Parser interface {
 	URLPatterns() []string
 }
 
-func extractAllBetween(body, prefix, suffix string) []string {
-	var result []string
-	tokens := strings.Split(body, prefix)
-	for i, t := range tokens {
-		if i == 0 {
-			continue
+// BannerParser is implemented by parsers that read token reputation and warning banners
+type BannerParser interface {
+	ExtractReputation(body string) string
+	ExtractWarnings(body string) []string
+}
+
+// IdentifiedParser is implemented by parsers that are told apart by more than their explorer,
+// such as rule files
+type IdentifiedParser interface {
+	ID() string
+}
+
+// ReportScanner is implemented by parsers that gather more than the pages behind URLPatterns
+type ReportScanner interface {
+	ScanReport(ctx context.Context, address string) (*domain.AddressReport, error)
+}
+
+// getBody fetches the page through the host's rate limiter, retrying with backoff
+// when the explorer rate-limits or fails
+func getBody(ctx context.Context, url string) (string, error) {
+	l := limiterFor(url)
+	for attempt := 0; ; attempt++ {
+		if err := l.wait(ctx); err != nil {
+			return "", err
+		}
+		body, err := fetch(ctx, url)
+		if err == nil {
+			return body, nil
 		}
-		tag := strings.TrimSpace(strings.Split(t, suffix)[0])
-		if tag != "" {
-			result = append(result, tag)
+		if attempt >= l.config.MaxRetries || !retryable(err) || ctx.Err() != nil {
+			return "", err
 		}
+		delay := l.backoff(attempt, err)
+		log.WithError(err).WithFields(log.Fields{
+			"url":     redactURL(url),
+			"attempt": attempt + 1,
+			"delay":   delay.String(),
+		}).Warn("error getting page (backing off)")
+		l.pause(delay)
 	}
-	return result
 }
 
-func getBody(url string) (string, error) {
-	res, err := http.Get(url)
+func getReportFromPage(ctx context.Context, p Parser, url string) (*domain.AddressReport, error) {
+	body, err := getBody(ctx, url)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer res.Body.Close()
-	b, err := io.ReadAll(res.Body)
-	return strings.ToLower(string(b)), err
-}
 
-func getReportFromPage(p Parser, url string) *domain.AddressReport {
-	logger := log.WithFields(log.Fields{
-		"url": url,
-	})
-	body, err := getBody(url)
-	if err != nil {
-		logger.WithError(err).Error("error getting page (skipping)")
-		return nil
+	now := time.Now()
+	rp := &domain.AddressReport{LastChecked: now}
+	pg, extractsPage := p.(pageExtractor)
+	if extractsPage {
+		pe := pg.extractPage(body)
+		rp.Name, rp.Tags, rp.Reputation, rp.Warnings = pe.Name, pe.Tags, pe.Reputation, pe.Warnings
+	} else {
+		rp.Name = p.ExtractName(body)
+		rp.Tags = p.ExtractTags(body)
+	}
+	for _, t := range rp.Tags {
+		rp.AddProvenance(t, &domain.Provenance{
+			SourceURL: redactURL(url),
+			ParserID:  ParserID(p),
+			FirstSeen: now,
+			LastSeen:  now,
+		})
+	}
+	if bp, ok := p.(BannerParser); ok && !extractsPage {
+		rp.Reputation = bp.ExtractReputation(body)
+		rp.Warnings = bp.ExtractWarnings(body)
 	}
+	return rp, nil
+}
 
-	return &domain.AddressReport{
-		Name:        p.ExtractName(body),
-		Tags:        p.ExtractTags(body),
-		LastChecked: time.Now(),
+// Scan merges the reports from every page of the parser. Pages that don't exist are skipped,
+// any other fetch error fails the scan so that an incomplete report is never cached.
+func Scan(ctx context.Context, p Parser, address string) (*domain.AddressReport, error) {
+	if rs, ok := p.(ReportScanner); ok {
+		return rs.ScanReport(ctx, address)
 	}
+	return scanPages(ctx, p, address)
 }
 
-func Scan(p Parser, address string) *domain.AddressReport {
+func scanPages(ctx context.Context, p Parser, address string) (*domain.AddressReport, error) {
 	rp := &domain.AddressReport{}
+	found := false
+	var notFound error
 	for _, up := range p.URLPatterns() {
-		ar := getReportFromPage(p, fmt.Sprintf(up, address))
-		if ar != nil {
-			rp.Merge(ar)
+		url := fmt.Sprintf(up, address)
+		ar, err := getReportFromPage(ctx, p, url)
+		if errors.Is(err, ErrNotFound) {
+			log.WithField("url", url).Debug("page not found (skipping)")
+			notFound = err
+			continue
+		}
+		if err != nil {
+			return nil, err
 		}
+		found = true
+		rp.Merge(ar)
+	}
+	if !found && notFound != nil {
+		return nil, notFound
+	}
+	return rp, nil
+}
+
+// Source names the explorer a parser reads, e.g. "etherscan.io"
+func Source(p Parser) string {
+	if p == nil || len(p.URLPatterns()) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(hostOf(p.URLPatterns()[0]), "www.")
+}
+
+// ParserID names the parser in tag provenance: the explorer, qualified by the rule file id
+// for rule-driven parsers
+func ParserID(p Parser) string {
+	if ip, ok := p.(IdentifiedParser); ok && ip.ID() != "" {
+		return fmt.Sprintf("%s/%s", Source(p), ip.ID())
 	}
-	return rp
+	return Source(p)
 }
 
 func NewParser(chainID int64) Parser {
-	if chainID == 1 {
+	switch chainID {
+	case 1:
 		return &mainnetParser{}
-	}
-	if chainID == 56 {
+	case 10:
+		return &optimismParser{}
+	case 56:
 		return &bscParser{}
+	case 137:
+		return &polygonParser{}
+	case 250:
+		return &fantomParser{}
+	case 8453:
+		return &baseParser{}
+	case 42161:
+		return &arbitrumParser{}
+	case 43114:
+		return newAvalancheParser()
+	}
+	if u, ok := blockscoutURLs[chainID]; ok {
+		return NewBlockscoutParser(u)
 	}
 	return nil
 }
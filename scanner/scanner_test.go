@@ -0,0 +1,96 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type pathParser struct {
+	mainnetParser
+	patterns []string
+}
+
+func (p *pathParser) URLPatterns() []string {
+	return p.patterns
+}
+
+func TestScan_FetchErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/challenge/0x1":
+			w.Header().Set("cf-mitigated", "challenge")
+			w.WriteHeader(http.StatusForbidden)
+		case "/interstitial/0x1":
+			_, _ = w.Write([]byte("<html><head><title>Just a moment...</title></head></html>"))
+		case "/limited/0x1":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/broken/0x1":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/tagged/0x1":
+			_, _ = w.Write([]byte(`<html><body><span><i class="fa-hashtag"></i>Heist</span></body></html>`))
+		case "/ok/0x1":
+			_, _ = w.Write([]byte("<html><head><title>Foo: Bar | Address 0x1 | Etherscan</title></head></html>"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	SetRateLimit(&pathParser{patterns: []string{srv.URL}}, RateLimit{MaxRetries: 1, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	tests := []struct {
+		Name     string
+		Paths    []string
+		Expected error
+	}{
+		{"cloudflare header", []string{"/ok/%s", "/challenge/%s"}, ErrBlocked},
+		{"cloudflare page", []string{"/interstitial/%s"}, ErrBlocked},
+		{"rate limited", []string{"/limited/%s"}, ErrRateLimited},
+		{"server error", []string{"/broken/%s"}, ErrServerError},
+		{"all not found", []string{"/missing/%s"}, ErrNotFound},
+	}
+	for _, test := range tests {
+		var patterns []string
+		for _, p := range test.Paths {
+			patterns = append(patterns, srv.URL+p)
+		}
+		res, err := Scan(context.Background(), &pathParser{patterns: patterns}, "0x1")
+		assert.Nil(t, res, test.Name)
+		assert.True(t, errors.Is(err, test.Expected), test.Name)
+		var fe *FetchError
+		assert.True(t, errors.As(err, &fe), test.Name)
+	}
+
+	res, err := Scan(context.Background(), &pathParser{patterns: []string{srv.URL + "/missing/%s", srv.URL + "/ok/%s"}}, "0x1")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo: bar", res.Name)
+
+	res, err = Scan(context.Background(), &pathParser{patterns: []string{srv.URL + "/tagged/%s", srv.URL + "/ok/%s"}}, "0x1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"heist"}, res.Tags)
+	assert.Len(t, res.Provenance["heist"], 1)
+	assert.Equal(t, srv.URL+"/tagged/0x1", res.Provenance["heist"][0].SourceURL)
+	assert.Equal(t, hostOf(srv.URL), res.Provenance["heist"][0].ParserID)
+	assert.False(t, res.FirstSeen("heist").IsZero())
+}
+
+func TestParserID(t *testing.T) {
+	assert.Equal(t, "etherscan.io", ParserID(NewParser(1)))
+	rf, err := LoadRuleFile("./rules/1.json")
+	assert.NoError(t, err)
+	p, err := NewRuleParser(rf)
+	assert.NoError(t, err)
+	assert.Equal(t, "etherscan.io/etherscan", ParserID(p))
+}
+
+func TestSource(t *testing.T) {
+	assert.Equal(t, "etherscan.io", Source(NewParser(1)))
+	assert.Equal(t, "bscscan.com", Source(NewParser(56)))
+	assert.Equal(t, "gnosis.blockscout.com", Source(NewParser(100)))
+	assert.Equal(t, "", Source(nil))
+}
@@ -0,0 +1,45 @@
+package scanner
+
+import (
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// compileSelector compiles a comma-separated group of css selectors with cascadia. Pages are
+// lowercased when fetched, so the selectors are too.
+func compileSelector(s string) (cascadia.SelectorGroup, error) {
+	return cascadia.ParseGroup(strings.ToLower(s))
+}
+
+func getAttr(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// nodeText returns the visible text under n with whitespace collapsed
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	var visit func(c *html.Node)
+	visit = func(c *html.Node) {
+		if c.Type == html.TextNode && !isInScript(c) {
+			sb.WriteString(c.Data)
+			sb.WriteByte(' ')
+		}
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			visit(child)
+		}
+	}
+	visit(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+func isInScript(n *html.Node) bool {
+	p := n.Parent
+	return p != nil && p.Type == html.ElementNode && (p.Data == "script" || p.Data == "style")
+}
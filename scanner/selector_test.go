@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+const selectorDoc = `<html><head><title>Doc</title></head><body>
+<div id="labels" class="d-flex gap-1">
+<a class="badge" href="/accounts/label/bridge"><i class="far fa-hashtag"></i> Bridge</a>
+<span class="badge bg-danger"><i class="far fa-hashtag"></i> Heist</span>
+<span class="wrapper"><a href="/other"><i class="far fa-hashtag"></i> Nested</a></span>
+</div>
+<p data-kind="warning">Reported for <b>phishing</b><script>var x = 1;</script></p>
+</body></html>`
+
+func findText(t *testing.T, sel string) []string {
+	doc, err := html.Parse(strings.NewReader(selectorDoc))
+	assert.NoError(t, err)
+	s, err := compileSelector(sel)
+	assert.NoError(t, err)
+	var result []string
+	for _, n := range cascadia.QueryAll(doc, s) {
+		result = append(result, nodeText(n))
+	}
+	return result
+}
+
+func TestSelector_FindAll(t *testing.T) {
+	tests := []struct {
+		sel      string
+		expected []string
+	}{
+		{"title", []string{"Doc"}},
+		{"span.bg-danger", []string{"Heist"}},
+		{"#labels > a", []string{"Bridge"}},
+		{`a[href^="/accounts/label/"]`, []string{"Bridge"}},
+		{`p[data-kind*=warn]`, []string{"Reported for phishing"}},
+		{"span:haschild(i.fa-hashtag)", []string{"Heist"}},
+		{"a:haschild(i.fa-hashtag), span.bg-danger", []string{"Bridge", "Heist", "Nested"}},
+		// selectors are lowercased like the pages they match
+		{"SPAN.BG-Danger", []string{"Heist"}},
+		{"div.missing", nil},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, findText(t, test.sel), test.sel)
+	}
+}
+
+func TestSelector_CompileErrors(t *testing.T) {
+	for _, sel := range []string{"", "div >", "> div", "a[href", "a:has(span", "div..x"} {
+		_, err := compileSelector(sel)
+		assert.Error(t, err, sel)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	label_api "forta-network/go-agent/label-api"
 	"github.com/forta-network/forta-core-go/protocol"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
 	"golang.org/x/sync/errgroup"
 
 	"forta-network/go-agent/domain"
@@ -32,46 +33,97 @@ type Agent struct {
 	protocol.UnimplementedAgentServer
 	Mux      sync.Mutex
 	lastSync time.Time
-	State    map[string]*domain.AddressReport
-	started  bool
-	Parser   scanner.Parser
-	LStore   store.LabelStore
+	// State defaults to a cache of defaultCacheSize reports
+	State   ReportCache
+	started bool
+	Parser  scanner.Parser
+	LStore  store.LabelStore
+	// Filter drops addresses before they are scanned, nil scans every address
+	Filter *AddressFilter
+	// AsyncScans scans addresses in the background instead of within EvaluateTx, publishing
+	// the labels on a later response. QueueDepth and QueueWorkers size the queue.
+	AsyncScans    bool
+	QueueDepth    int
+	QueueWorkers  int
+	queue         *scanQueue
+	queueOnce     sync.Once
+	queuedChanges *labelChanges
+	// LabelAPI defaults to the public Forta label api
+	LabelAPI        label_api.Client
+	Canaries        []*scanner.Canary
+	CanaryInterval  time.Duration
+	lastCanaryCheck time.Time
+	// SweepInterval is how often a batch of SweepBatchSize stored entities is re-verified
+	SweepInterval   time.Duration
+	SweepBatchSize  int
+	lastSweep       time.Time
+	sweepCursor     string
+	sweeping        bool
+	pendingFindings []*protocol.Finding
 }
 
-func (a *Agent) checkAddress(addr string) *domain.AddressReport {
+// state returns the report cache, creating the default one on first use
+func (a *Agent) state() ReportCache {
+	a.Mux.Lock()
+	defer a.Mux.Unlock()
+	if a.State == nil {
+		a.State = NewReportCache(defaultCacheSize, defaultCacheTTL)
+	}
+	return a.State
+}
+
+// checkAddress returns the report for the address, and what changed when it replaced an
+// expired report (nil otherwise)
+func (a *Agent) checkAddress(ctx context.Context, addr string) (*domain.AddressReport, *domain.ReportDiff) {
 	if a.Parser == nil {
-		return nil
+		return nil, nil
 	}
-	a.Mux.Lock()
-	if s, ok := a.State[addr]; ok {
-		if time.Since(s.LastChecked) < 72*time.Hour {
-			a.Mux.Unlock()
-			return s
+	s, known := a.state().Get(addr)
+	if known && time.Since(s.LastChecked) < 72*time.Hour {
+		return s, nil
+	}
+
+	if !known {
+		exists, err := a.LStore.EntityExists(ctx, addr)
+		if err != nil {
+			log.WithError(err).Error("error checking for existing entity (ignoring)")
+			return nil, nil
+		}
+		if exists {
+			log.WithField("entity", addr).Info("address exists in cache, skipping")
+			return nil, nil
 		}
 	}
-	a.Mux.Unlock()
 
-	exists, err := a.LStore.EntityExists(context.Background(), addr)
+	return a.scanAddress(ctx, addr)
+}
+
+// scanAddress scans the address and replaces its report in State, returning nil on failure.
+// The diff against the replaced report is nil if there was none.
+func (a *Agent) scanAddress(ctx context.Context, addr string) (*domain.AddressReport, *domain.ReportDiff) {
+	rp, err := scanner.Scan(ctx, a.Parser, addr)
 	if err != nil {
-		log.WithError(err).Error("error checking for existing entity (ignoring)")
-		return nil
-	}
-	if exists {
-		log.WithField("entity", addr).Info("address exists in cache, skipping")
-		return nil
+		log.WithError(err).WithField("entity", addr).Error("error scanning address (not caching)")
+		return nil, nil
 	}
-
-	rp := scanner.Scan(a.Parser, addr)
 	rp.LastChecked = time.Now()
-	if a.State == nil {
-		a.State = make(map[string]*domain.AddressReport)
+	previous, ok := a.state().Get(addr)
+	rp.KeepFirstSeen(previous)
+	// a re-scan replaces the expired report so that retracted tags are dropped
+	a.state().Put(addr, rp)
+	if !ok {
+		return rp, nil
 	}
-	if s, ok := a.State[addr]; !ok {
-		a.State[addr] = rp
-	} else {
-		s.Merge(rp)
+	d := domain.Diff(previous, rp)
+	if !d.IsEmpty() {
+		log.WithFields(log.Fields{
+			"entity":  addr,
+			"added":   d.AddedTags,
+			"removed": d.RemovedTags,
+			"name":    d.Name,
+		}).Info("address report changed")
 	}
-	return a.State[addr]
+	return rp, d
 }
 
 func (a *Agent) Initialize(ctx context.Context, request *protocol.InitializeRequest) (*protocol.InitializeResponse, error) {
@@ -93,16 +145,22 @@ func errorMsg(msg string) *protocol.EvaluateTxResponse {
 	}
 }
 
-func (a *Agent) filterOutDuplicates(ls []*protocol.Label) ([]*protocol.Label, []*protocol.Label) {
-	c := label_api.NewClient(nil)
+func (a *Agent) filterOutDuplicates(ctx context.Context, ls []*protocol.Label) ([]*protocol.Label, []*protocol.Label) {
+	c := a.labelAPI()
 	var result []*protocol.Label
 	var duplicates []*protocol.Label
-	for _, proposed := range ls {
-		l, err := a.LStore.GetLabel(context.Background(), proposed.Entity, proposed.Label)
-		if err != nil {
-			log.WithError(err).Error("error checking cache for duplicate detection (ignoring to avoid downtime)")
-		}
-		if l != nil {
+	keys := make([]store.EntityLabel, len(ls))
+	for i, proposed := range ls {
+		keys[i] = store.EntityLabel{Entity: proposed.Entity, Label: proposed.Label}
+	}
+	// one round-trip for the whole transaction
+	cached, err := a.LStore.GetLabels(ctx, keys)
+	if err != nil {
+		log.WithError(err).Error("error checking cache for duplicate detection (ignoring to avoid downtime)")
+		cached = make([]*store.Label, len(ls))
+	}
+	for i, proposed := range ls {
+		if cached[i] != nil {
 			log.WithFields(log.Fields{
 				"label":  proposed.Label,
 				"entity": proposed.Entity,
@@ -127,7 +185,8 @@ func (a *Agent) filterOutDuplicates(ls []*protocol.Label) ([]*protocol.Label, []
 				"entity": proposed.Entity,
 			}).Info("label already exists (avoiding duplicate)")
 
-			if err := a.LStore.PutLabel(context.Background(), proposed.Entity, proposed.Label); err != nil {
+			// the transaction it was first published for is unknown
+			if err := a.LStore.PutLabel(ctx, proposed.Entity, proposed.Label, a.labelSource("", proposed)); err != nil {
 				log.WithError(err).Error("error syncing existing label to cache (ignoring)")
 			}
 
@@ -160,11 +219,170 @@ func toJson(i interface{}) string {
 	return string(b)
 }
 
+// publishLabels syncs the cache with the labels about to be published for the transaction,
+// if any
+func (a *Agent) publishLabels(ctx context.Context, txHash string, newLabels, removals []*protocol.Label) {
+	for _, l := range newLabels {
+		if err := a.LStore.PutLabel(ctx, l.Entity, l.Label, a.labelSource(txHash, l)); err != nil {
+			log.WithError(err).Error("error syncing existing label to cache (ignoring)")
+		}
+	}
+	a.deleteRemovedLabels(ctx, removals)
+}
+
+// labelSource records the transaction and the first explorer page the label was seen on
+func (a *Agent) labelSource(txHash string, l *protocol.Label) store.LabelSource {
+	src := store.LabelSource{TxHash: txHash}
+	ar, _ := a.state().Get(l.Entity)
+	if ps := tagProvenance(ar, l.Label); len(ps) > 0 {
+		src.SourceURL = ps[0].SourceURL
+	}
+	return src
+}
+
+// labelSources lists the pages each new label was seen on, by address and label
+func (a *Agent) labelSources(ls []*protocol.Label) map[string]map[string][]string {
+	res := make(map[string]map[string][]string)
+	for _, l := range ls {
+		ar, _ := a.state().Get(l.Entity)
+		var urls []string
+		for _, p := range tagProvenance(ar, l.Label) {
+			if !slices.Contains(urls, p.SourceURL) {
+				urls = append(urls, p.SourceURL)
+			}
+		}
+		if len(urls) == 0 {
+			continue
+		}
+		if _, ok := res[l.Entity]; !ok {
+			res[l.Entity] = make(map[string][]string)
+		}
+		res[l.Entity][l.Label] = urls
+	}
+	return res
+}
+
+// labelConflicts lists the explorer conflicts of the addresses with new labels
+func (a *Agent) labelConflicts(ls []*protocol.Label) map[string][]domain.Conflict {
+	res := make(map[string][]domain.Conflict)
+	for _, l := range ls {
+		ar, _ := a.state().Get(l.Entity)
+		if ar != nil && len(ar.Conflicts) > 0 {
+			res[l.Entity] = ar.Conflicts
+		}
+	}
+	return res
+}
+
+// scoreLabels sets the confidence of each new label and explains it by address and label. New
+// labels aren't live in the label store, so they are aged by when their tag was first seen,
+// which re-scans carry over.
+func (a *Agent) scoreLabels(ls []*protocol.Label) map[string]map[string]string {
+	source := scanner.Source(a.Parser)
+	res := make(map[string]map[string]string)
+	for _, l := range ls {
+		ar, _ := a.state().Get(l.Entity)
+		score, reasons := scoreLabel(l.Label, ar, labelEvidenceFor(source, ar, l.Label))
+		l.Confidence = float32(score)
+		if _, ok := res[l.Entity]; !ok {
+			res[l.Entity] = make(map[string]string)
+		}
+		res[l.Entity][l.Label] = fmt.Sprintf("%.2f: %s", score, strings.Join(reasons, ", "))
+	}
+	return res
+}
+
+// labelChanges are the labels and report changes to publish in one finding
+type labelChanges struct {
+	added      []*protocol.Label
+	duplicates []*protocol.Label
+	removed    []*protocol.Label
+	// diffs holds the report changes of re-scanned addresses, by address
+	diffs map[string]*domain.ReportDiff
+	// txHashes holds the transaction each address was queued for, by address
+	txHashes map[string]string
+}
+
+// add records the labels of one address, and what changed since its previous report
+func (c *labelChanges) add(address string, labels, removed []*protocol.Label, d *domain.ReportDiff) {
+	c.added = append(c.added, labels...)
+	c.removed = append(c.removed, removed...)
+	if d != nil && !d.IsEmpty() {
+		if c.diffs == nil {
+			c.diffs = make(map[string]*domain.ReportDiff)
+		}
+		c.diffs[address] = d
+	}
+}
+
+// changedLabels computes the labels of a scanned address, and the removals when a previous
+// report changed or is unknown and every explorer was scanned
+func (a *Agent) changedLabels(ctx context.Context, address string, ar *domain.AddressReport, d *domain.ReportDiff, rescanned bool) ([]*protocol.Label, []*protocol.Label) {
+	labels := reportLabels(address, ar)
+	if !rescanned || (d != nil && d.IsEmpty()) {
+		return labels, nil
+	}
+	if ar.Partial {
+		// tags of the explorers that failed would be retracted
+		log.WithField("entity", address).Warn("partial report, not removing any labels")
+		return labels, nil
+	}
+	return labels, a.removedLabels(ctx, address, labels)
+}
+
+// labelFinding builds the finding for label changes about to be published, scoring the new labels
+func (a *Agent) labelFinding(alertID, name string, c *labelChanges) *protocol.Finding {
+	newLabels, duplicates, removals := c.added, c.duplicates, c.removed
+	md := map[string]string{
+		"timestamp":  time.Now().UTC().Format(time.RFC3339),
+		"added":      toJson(summarizeToMap(newLabels)),
+		"duplicates": toJson(summarizeToMap(duplicates)),
+		"removed":    toJson(summarizeToMap(removals)),
+		"confidence": toJson(a.scoreLabels(newLabels)),
+		"sources":    toJson(a.labelSources(newLabels)),
+	}
+	if len(c.diffs) > 0 {
+		md["changes"] = toJson(c.diffs)
+	}
+	if conflicts := a.labelConflicts(newLabels); len(conflicts) > 0 {
+		md["conflicts"] = toJson(conflicts)
+	}
+	return &protocol.Finding{
+		Protocol:    "ethereum",
+		Severity:    protocol.Finding_INFO,
+		Type:        protocol.Finding_INFORMATION,
+		AlertId:     alertID,
+		Name:        name,
+		Metadata:    md,
+		Labels:      append(append([]*protocol.Label{}, newLabels...), removals...),
+		Description: fmt.Sprintf("Addresses, %d new, %d dupes, %d removed", len(newLabels), len(duplicates), len(removals)),
+	}
+}
+
+// skipAddress reports whether the filter drops the address
+func (a *Agent) skipAddress(address string, ev *protocol.TransactionEvent) bool {
+	if a.Filter == nil {
+		return false
+	}
+	skip, reason := a.Filter.Skip(address, ev)
+	if skip {
+		log.WithFields(log.Fields{
+			"entity": address,
+			"reason": reason,
+		}).Debug("skipping address")
+	}
+	return skip
+}
+
 func (a *Agent) EvaluateTx(ctx context.Context, request *protocol.EvaluateTxRequest) (*protocol.EvaluateTxResponse, error) {
+	if a.AsyncScans {
+		return a.enqueueTx(ctx, request), nil
+	}
 	mux := sync.Mutex{}
-	grp, ctx := errgroup.WithContext(ctx)
+	// the group context is cancelled once Wait returns, so it is only used by the workers
+	grp, grpCtx := errgroup.WithContext(ctx)
 	addresses := make(chan string)
-	var result []*protocol.Label
+	changes := &labelChanges{}
 	workers := 10
 	if workers > len(request.Event.Addresses) {
 		workers = len(request.Event.Addresses)
@@ -173,30 +391,14 @@ func (a *Agent) EvaluateTx(ctx context.Context, request *protocol.EvaluateTxRequ
 	for i := 0; i < workers; i++ {
 		grp.Go(func() error {
 			for address := range addresses {
-				ar := a.checkAddress(address)
+				ar, d := a.checkAddress(grpCtx, address)
 				if ar == nil {
 					continue
 				}
-				for _, t := range ar.Tags {
-					mux.Lock()
-					result = append(result, &protocol.Label{
-						EntityType: protocol.Label_ADDRESS,
-						Entity:     address,
-						Confidence: 1,
-						Label:      strings.ToLower(t),
-					})
-					mux.Unlock()
-				}
-				if ar.Name != "" {
-					mux.Lock()
-					result = append(result, &protocol.Label{
-						EntityType: protocol.Label_ADDRESS,
-						Entity:     address,
-						Confidence: 1,
-						Label:      fmt.Sprintf("name|%s", strings.ReplaceAll(ar.Name, "|", "_")),
-					})
-					mux.Unlock()
-				}
+				labels, removed := a.changedLabels(grpCtx, address, ar, d, d != nil)
+				mux.Lock()
+				changes.add(address, labels, removed, d)
+				mux.Unlock()
 			}
 			return nil
 		})
@@ -205,7 +407,14 @@ func (a *Agent) EvaluateTx(ctx context.Context, request *protocol.EvaluateTxRequ
 	grp.Go(func() error {
 		defer close(addresses)
 		for address := range request.Event.Addresses {
-			addresses <- address
+			if a.skipAddress(address, request.Event) {
+				continue
+			}
+			select {
+			case addresses <- address:
+			case <-grpCtx.Done():
+				return grpCtx.Err()
+			}
 		}
 		return nil
 	})
@@ -215,43 +424,22 @@ func (a *Agent) EvaluateTx(ctx context.Context, request *protocol.EvaluateTxRequ
 		return errorMsg(err.Error()), nil
 	}
 
-	newLabels, duplicates := a.filterOutDuplicates(result)
-	if len(newLabels) > 0 {
+	changes.added, changes.duplicates = a.filterOutDuplicates(ctx, changes.added)
+	newLabels, removals := changes.added, changes.removed
+	if len(newLabels) > 0 || len(removals) > 0 {
 		log.WithFields(
 			log.Fields{
-				"tx":     request.Event.Transaction.Hash,
-				"labels": len(newLabels),
+				"tx":      request.Event.Transaction.Hash,
+				"labels":  len(newLabels),
+				"removed": len(removals),
 			}).Info("returning finding")
 
-		for _, l := range newLabels {
-			if err := a.LStore.PutLabel(ctx, l.Entity, l.Label); err != nil {
-				log.WithError(err).Error("error syncing existing label to cache (ignoring)")
-			}
-		}
-
-		newMap := summarizeToMap(newLabels)
-		dupeMap := summarizeToMap(duplicates)
-
-		md := map[string]string{
-			"timestamp":  time.Now().UTC().Format(time.RFC3339),
-			"added":      toJson(newMap),
-			"duplicates": toJson(dupeMap),
-		}
+		f := a.labelFinding("label-sync", "Syncing Labels", changes)
+		a.publishLabels(ctx, request.Event.Transaction.GetHash(), newLabels, removals)
 
 		return &protocol.EvaluateTxResponse{
-			Status: protocol.ResponseStatus_SUCCESS,
-			Findings: []*protocol.Finding{
-				{
-					Protocol:    "ethereum",
-					Severity:    protocol.Finding_INFO,
-					Type:        protocol.Finding_INFORMATION,
-					AlertId:     "label-sync",
-					Name:        "Syncing Labels",
-					Metadata:    md,
-					Labels:      newLabels,
-					Description: fmt.Sprintf("Addresses, %d new, %d dupes", len(newLabels), len(duplicates)),
-				},
-			},
+			Status:    protocol.ResponseStatus_SUCCESS,
+			Findings:  []*protocol.Finding{f},
 			Timestamp: time.Now().UTC().Format(time.RFC3339),
 		}, nil
 	}
@@ -267,9 +455,14 @@ func (a *Agent) EvaluateTx(ctx context.Context, request *protocol.EvaluateTxRequ
 }
 
 func (a *Agent) EvaluateBlock(ctx context.Context, request *protocol.EvaluateBlockRequest) (*protocol.EvaluateBlockResponse, error) {
+	stats := a.state().Stats()
 	resp := &protocol.EvaluateBlockResponse{
-		Status:    protocol.ResponseStatus_SUCCESS,
-		Metadata:  map[string]string{},
+		Status: protocol.ResponseStatus_SUCCESS,
+		Metadata: map[string]string{
+			"stateEntries":       fmt.Sprint(stats.Entries),
+			"stateSizeEvictions": fmt.Sprint(stats.SizeEvictions),
+			"stateAgeEvictions":  fmt.Sprint(stats.AgeEvictions),
+		},
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 	}
 
@@ -285,5 +478,16 @@ func (a *Agent) EvaluateBlock(ctx context.Context, request *protocol.EvaluateBlo
 		})
 	}
 
+	resp.Findings = append(resp.Findings, a.takePendingFindings()...)
+	if f := a.takeQueuedFinding(ctx); f != nil {
+		resp.Findings = append(resp.Findings, f)
+	}
+	if a.canariesDue() {
+		go a.checkCanaries()
+	}
+	if a.sweepDue() {
+		go a.sweep()
+	}
+
 	return resp, nil
 }
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/stretchr/testify/assert"
+
+	"forta-network/go-agent/store"
+)
+
+func TestAgent_FilterOutDuplicates(t *testing.T) {
+	ctx := context.Background()
+	ls := store.NewMemoryLabelStore()
+	assert.NoError(t, ls.PutLabel(ctx, "0x1", "exploit|heist", store.LabelSource{}))
+	a := &Agent{
+		LStore:   ls,
+		LabelAPI: &fakeLabelAPI{labels: []*protocol.Label{addressLabel("0x2", "scam|phish / hack")}},
+	}
+
+	added, duplicates := a.filterOutDuplicates(ctx, []*protocol.Label{
+		addressLabel("0x1", "exploit|heist"),
+		addressLabel("0x1", "name|exploiter"),
+		addressLabel("0x2", "scam|phish / hack"),
+	})
+	assert.Equal(t, []*protocol.Label{addressLabel("0x1", "name|exploiter")}, added)
+	assert.Len(t, duplicates, 2)
+
+	// labels only known to the label api are synced to the cache
+	l, err := ls.GetLabel(ctx, "0x2", "scam|phish / hack")
+	assert.NoError(t, err)
+	assert.NotNil(t, l)
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"forta-network/go-agent/domain"
+)
+
+const (
+	defaultCacheSize = 100000
+	// reports are kept past the 72h re-scan age so that re-scans can be diffed against them
+	defaultCacheTTL = 7 * 24 * time.Hour
+)
+
+// ReportCache holds the latest report of each scanned address
+type ReportCache interface {
+	Get(address string) (*domain.AddressReport, bool)
+	Put(address string, ar *domain.AddressReport)
+	Stats() CacheStats
+}
+
+// CacheStats counts the entries of a cache and why entries were evicted
+type CacheStats struct {
+	Entries       int    `json:"entries"`
+	SizeEvictions uint64 `json:"sizeEvictions"`
+	AgeEvictions  uint64 `json:"ageEvictions"`
+}
+
+type cacheEntry struct {
+	address string
+	report  *domain.AddressReport
+	stored  time.Time
+}
+
+// lruCache evicts the least recently used report beyond its size, and reports older than its ttl
+type lruCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+	stats   CacheStats
+}
+
+// NewReportCache returns an LRU cache of at most size reports, each kept for at most ttl
+func NewReportCache(size int, ttl time.Duration) ReportCache {
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	return &lruCache{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *lruCache) Get(address string) (*domain.AddressReport, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[address]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if time.Since(e.stored) > c.ttl {
+		c.remove(el)
+		c.stats.AgeEvictions++
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return e.report, true
+}
+
+func (c *lruCache) Put(address string, ar *domain.AddressReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[address]; ok {
+		e := el.Value.(*cacheEntry)
+		e.report = ar
+		e.stored = time.Now()
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[address] = c.order.PushFront(&cacheEntry{address: address, report: ar, stored: time.Now()})
+	// expired reports are dropped lazily: by Get, or here once they are the least recently used
+	for el := c.order.Back(); el != nil && time.Since(el.Value.(*cacheEntry).stored) > c.ttl; el = c.order.Back() {
+		c.remove(el)
+		c.stats.AgeEvictions++
+	}
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+		c.stats.SizeEvictions++
+	}
+}
+
+func (c *lruCache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*cacheEntry).address)
+}
+
+// Stats counts expired reports that weren't dropped yet as entries
+func (c *lruCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	return stats
+}
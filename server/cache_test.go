@@ -0,0 +1,51 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"forta-network/go-agent/domain"
+)
+
+func TestLRUCache_Size(t *testing.T) {
+	c := NewReportCache(2, time.Hour)
+	c.Put("0x1", &domain.AddressReport{Name: "one"})
+	c.Put("0x2", &domain.AddressReport{Name: "two"})
+	// reading 0x1 makes 0x2 the least recently used
+	_, ok := c.Get("0x1")
+	assert.True(t, ok)
+	c.Put("0x3", &domain.AddressReport{Name: "three"})
+
+	_, ok = c.Get("0x2")
+	assert.False(t, ok)
+	ar, ok := c.Get("0x1")
+	assert.True(t, ok)
+	assert.Equal(t, "one", ar.Name)
+
+	// replacing an entry doesn't evict
+	c.Put("0x3", &domain.AddressReport{Name: "three again"})
+	ar, _ = c.Get("0x3")
+	assert.Equal(t, "three again", ar.Name)
+	assert.Equal(t, CacheStats{Entries: 2, SizeEvictions: 1}, c.Stats())
+}
+
+func TestLRUCache_TTL(t *testing.T) {
+	c := NewReportCache(10, time.Hour).(*lruCache)
+	c.Put("0x1", &domain.AddressReport{})
+	c.Put("0x2", &domain.AddressReport{})
+	c.Put("0x3", &domain.AddressReport{})
+	c.entries["0x1"].Value.(*cacheEntry).stored = time.Now().Add(-2 * time.Hour)
+	c.entries["0x2"].Value.(*cacheEntry).stored = time.Now().Add(-2 * time.Hour)
+
+	_, ok := c.Get("0x1")
+	assert.False(t, ok)
+	assert.Equal(t, CacheStats{Entries: 2, AgeEvictions: 1}, c.Stats())
+
+	// the least recently used report is dropped by the next Put once it expired
+	c.Put("0x4", &domain.AddressReport{})
+	_, ok = c.entries["0x2"]
+	assert.False(t, ok)
+	assert.Equal(t, CacheStats{Entries: 2, AgeEvictions: 2}, c.Stats())
+}
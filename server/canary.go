@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	log "github.com/sirupsen/logrus"
+
+	"forta-network/go-agent/scanner"
+)
+
+const (
+	defaultCanaryInterval = 6 * time.Hour
+	canaryTimeout         = 5 * time.Minute
+)
+
+func driftFinding(d *scanner.Drift) *protocol.Finding {
+	return &protocol.Finding{
+		Protocol:    "ethereum",
+		Severity:    protocol.Finding_LOW,
+		Type:        protocol.Finding_INFORMATION,
+		AlertId:     "parser-drift",
+		Name:        "Explorer Parser Drift",
+		Description: fmt.Sprintf("Expected labels for canary %s were not extracted", d.Address),
+		Metadata: map[string]string{
+			"timestamp":    time.Now().UTC().Format(time.RFC3339),
+			"address":      d.Address,
+			"expectedName": d.ExpectedName,
+			"actualName":   d.ActualName,
+			"missingTags":  toJson(d.MissingTags),
+		},
+	}
+}
+
+// canariesDue reports whether the canaries should be re-scanned, and marks them as checked if so
+func (a *Agent) canariesDue() bool {
+	interval := a.CanaryInterval
+	if interval == 0 {
+		interval = defaultCanaryInterval
+	}
+	a.Mux.Lock()
+	defer a.Mux.Unlock()
+	if a.Parser == nil || len(a.Canaries) == 0 || time.Since(a.lastCanaryCheck) < interval {
+		return false
+	}
+	a.lastCanaryCheck = time.Now()
+	return true
+}
+
+// checkCanaries scans every canary and queues a finding for each one that drifted
+func (a *Agent) checkCanaries() {
+	ctx, cancel := context.WithTimeout(context.Background(), canaryTimeout)
+	defer cancel()
+	for _, c := range a.Canaries {
+		d, err := scanner.CheckCanary(ctx, a.Parser, c)
+		if err != nil {
+			log.WithError(err).WithField("address", c.Address).Warn("error scanning canary (skipping)")
+			continue
+		}
+		if d == nil {
+			continue
+		}
+		log.WithFields(log.Fields{
+			"address":     d.Address,
+			"missingTags": d.MissingTags,
+			"actualName":  d.ActualName,
+		}).Warn("parser drift detected")
+		a.Mux.Lock()
+		a.pendingFindings = append(a.pendingFindings, driftFinding(d))
+		a.Mux.Unlock()
+	}
+}
+
+// takePendingFindings returns and clears the findings produced in the background
+func (a *Agent) takePendingFindings() []*protocol.Finding {
+	a.Mux.Lock()
+	defer a.Mux.Unlock()
+	findings := a.pendingFindings
+	a.pendingFindings = nil
+	return findings
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+
+	"forta-network/go-agent/domain"
+	"forta-network/go-agent/taxonomy"
+)
+
+// labelKindWeights is the base confidence of each kind of label: explorer-curated tags and
+// banners are deliberate, names scraped from page titles are often just descriptive
+var labelKindWeights = map[string]float64{
+	"tag":        0.9,
+	"warning":    0.9,
+	"contract":   0.9,
+	"proxy":      0.9,
+	"reputation": 0.8,
+	"name":       0.7,
+	// a category derived from the name rather than from a tag
+	"name-category": 0.6,
+}
+
+// sourceWeights scales the confidence by how closely the explorer curates its labels
+var sourceWeights = map[string]float64{
+	"etherscan.io":            1,
+	"optimistic.etherscan.io": 1,
+	"bscscan.com":             1,
+	"polygonscan.com":         1,
+	"arbiscan.io":             1,
+	"basescan.org":            1,
+	"ftmscan.com":             0.95,
+}
+
+const defaultSourceWeight = 0.85
+
+// categoryWeights scales tags whose category carries an explicit judgement above the rest
+var categoryWeights = map[taxonomy.Category]float64{
+	taxonomy.Scam:       1,
+	taxonomy.Exploit:    1,
+	taxonomy.Sanctioned: 1,
+	taxonomy.Mixer:      1,
+	taxonomy.Other:      0.85,
+}
+
+const (
+	defaultCategoryWeight = 0.95
+	// agreementBonus is added for every other explorer that reports the label
+	agreementBonus = 0.05
+	// tags that survived this long on the explorer were not a mistake
+	matureAge   = 30 * 24 * time.Hour
+	matureBonus = 0.05
+	// categories other explorers contradict are scaled down
+	conflictWeight = 0.8
+)
+
+// labelEvidence is what a label's confidence is scored from
+type labelEvidence struct {
+	// Source is the best curated explorer that reports the label
+	Source string
+	// Sources is how many explorers report the label
+	Sources int
+	// FirstSeen is when the label's tag was first seen
+	FirstSeen time.Time
+	// Conflicted is set when explorers disagree on the label's category
+	Conflicted bool
+}
+
+func sourceWeight(source string) float64 {
+	if sw, ok := sourceWeights[source]; ok {
+		return sw
+	}
+	return defaultSourceWeight
+}
+
+// labelKind returns the kind and, for categorized labels, the category of a published label
+func labelKind(label string, ar *domain.AddressReport) (string, taxonomy.Category) {
+	prefix, value, ok := strings.Cut(label, "|")
+	if !ok {
+		return "tag", taxonomy.Classify(label)
+	}
+	if _, ok := labelKindWeights[prefix]; ok {
+		return prefix, ""
+	}
+	c := taxonomy.Category(prefix)
+	if ar != nil && ar.Name != "" && value == strings.ToLower(ar.Name) && !slices.Contains(ar.Tags, ar.Name) {
+		return "name-category", c
+	}
+	return "tag", c
+}
+
+// scoreLabel returns the confidence of a label and the reasons behind it
+func scoreLabel(label string, ar *domain.AddressReport, ev labelEvidence) (float64, []string) {
+	kind, category := labelKind(label, ar)
+	score := labelKindWeights[kind]
+	reasons := []string{fmt.Sprintf("%s %.2f", kind, score)}
+
+	sw := sourceWeight(ev.Source)
+	score *= sw
+	reasons = append(reasons, fmt.Sprintf("source %s x%.2f", ev.Source, sw))
+
+	if category != "" {
+		cw, ok := categoryWeights[category]
+		if !ok {
+			cw = defaultCategoryWeight
+		}
+		score *= cw
+		reasons = append(reasons, fmt.Sprintf("category %s x%.2f", category, cw))
+	}
+	if ev.Conflicted {
+		score *= conflictWeight
+		reasons = append(reasons, fmt.Sprintf("conflicting explorers x%.2f", conflictWeight))
+	}
+	if ev.Sources > 1 {
+		bonus := agreementBonus * float64(ev.Sources-1)
+		score += bonus
+		reasons = append(reasons, fmt.Sprintf("%d explorers agree +%.2f", ev.Sources, bonus))
+	}
+	if !ev.FirstSeen.IsZero() && time.Since(ev.FirstSeen) >= matureAge {
+		score += matureBonus
+		reasons = append(reasons, fmt.Sprintf("seen since %s +%.2f", ev.FirstSeen.UTC().Format("2006-01-02"), matureBonus))
+	}
+	score = math.Round(math.Min(score, 1)*100) / 100
+	return score, reasons
+}
+
+// labelTag returns the tag a tag label was built from, "" for other kinds of labels
+func labelTag(label string, ar *domain.AddressReport) string {
+	if kind, _ := labelKind(label, ar); kind != "tag" {
+		return ""
+	}
+	if _, value, ok := strings.Cut(label, "|"); ok {
+		return value
+	}
+	return label
+}
+
+// tagProvenance returns the provenance of the tag behind a label
+func tagProvenance(ar *domain.AddressReport, label string) []*domain.Provenance {
+	tag := labelTag(label, ar)
+	if ar == nil || tag == "" {
+		return nil
+	}
+	var result []*domain.Provenance
+	for t, ps := range ar.Provenance {
+		if strings.EqualFold(t, tag) {
+			result = append(result, ps...)
+		}
+	}
+	return result
+}
+
+// labelEvidenceFor gathers the evidence for a label of the report from the provenance of its
+// tag, falling back to the source explorer for labels without any
+func labelEvidenceFor(source string, ar *domain.AddressReport, label string) labelEvidence {
+	ev := labelEvidence{Source: source, Sources: 1}
+	var parsers []string
+	for _, p := range tagProvenance(ar, label) {
+		if slices.Contains(parsers, p.ParserID) {
+			continue
+		}
+		parsers = append(parsers, p.ParserID)
+		// rule-driven parsers are qualified by their rule file id
+		host, _, _ := strings.Cut(p.ParserID, "/")
+		if len(parsers) == 1 || sourceWeight(host) > sourceWeight(ev.Source) {
+			ev.Source = host
+		}
+	}
+	if len(parsers) > 1 {
+		ev.Sources = len(parsers)
+	}
+	if tag := labelTag(label, ar); ar != nil && tag != "" {
+		ev.FirstSeen = ar.FirstSeen(tag)
+	}
+	if _, category := labelKind(label, ar); category != "" && ar != nil {
+		for _, c := range ar.Conflicts {
+			if c.Category == string(category) || c.OtherCategory == string(category) {
+				ev.Conflicted = true
+			}
+		}
+	}
+	return ev
+}
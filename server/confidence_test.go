@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/stretchr/testify/assert"
+
+	"forta-network/go-agent/domain"
+	"forta-network/go-agent/store"
+)
+
+func TestScoreLabel(t *testing.T) {
+	ar := &domain.AddressReport{Name: "fake_phishing5814", Tags: []string{"phish / hack", "bitfinex"}}
+	etherscan := labelEvidence{Source: "etherscan.io", Sources: 1}
+
+	score, reasons := scoreLabel("scam|phish / hack", ar, etherscan)
+	assert.Equal(t, 0.9, score)
+	assert.Equal(t, []string{"tag 0.90", "source etherscan.io x1.00", "category scam x1.00"}, reasons)
+
+	score, _ = scoreLabel("other|bitfinex", ar, etherscan)
+	assert.Equal(t, 0.77, score)
+
+	score, _ = scoreLabel("name|fake_phishing5814", ar, etherscan)
+	assert.Equal(t, 0.7, score)
+
+	score, _ = scoreLabel("scam|fake_phishing5814", ar, etherscan)
+	assert.Equal(t, 0.6, score)
+
+	score, _ = scoreLabel("scam|phish / hack", ar, labelEvidence{Source: "gnosis.blockscout.com", Sources: 1})
+	assert.Equal(t, 0.77, score)
+
+	score, reasons = scoreLabel("scam|phish / hack", ar, labelEvidence{
+		Source:    "etherscan.io",
+		Sources:   3,
+		FirstSeen: time.Now().Add(-60 * 24 * time.Hour),
+	})
+	assert.Equal(t, 1.0, score)
+	assert.Len(t, reasons, 5)
+}
+
+func TestLabelEvidenceFor(t *testing.T) {
+	firstSeen := time.Now().Add(-60 * 24 * time.Hour)
+	ar := &domain.AddressReport{Name: "exploiter", Tags: []string{"heist"}}
+	ar.AddProvenance("heist", &domain.Provenance{SourceURL: "https://etherscan.io/address/0x1", ParserID: "etherscan.io", FirstSeen: time.Now()})
+	ar.AddProvenance("heist", &domain.Provenance{SourceURL: "https://etherscan.io/token/0x1", ParserID: "etherscan.io", FirstSeen: firstSeen})
+
+	ev := labelEvidenceFor("etherscan.io", ar, "exploit|heist")
+	assert.Equal(t, labelEvidence{Source: "etherscan.io", Sources: 1, FirstSeen: firstSeen}, ev)
+
+	ev = labelEvidenceFor("etherscan.io", ar, "name|exploiter")
+	assert.True(t, ev.FirstSeen.IsZero())
+
+	// tags are scored by the explorers they were seen on
+	ar.AddProvenance("heist", &domain.Provenance{SourceURL: "https://ftmscan.com/address/0x1", ParserID: "ftmscan.com", FirstSeen: time.Now()})
+	ev = labelEvidenceFor("ftmscan.com", ar, "exploit|heist")
+	assert.Equal(t, "etherscan.io", ev.Source)
+	assert.Equal(t, 2, ev.Sources)
+	ar = &domain.AddressReport{Tags: []string{"heist"}}
+	ar.AddProvenance("heist", &domain.Provenance{SourceURL: "https://ftmscan.com/address/0x1", ParserID: "ftmscan.com/ftm-rules", FirstSeen: time.Now()})
+	assert.Equal(t, "ftmscan.com", labelEvidenceFor("etherscan.io", ar, "exploit|heist").Source)
+}
+
+func TestAgent_ScoreLabels(t *testing.T) {
+	ctx := context.Background()
+	firstSeen := time.Now().Add(-60 * 24 * time.Hour)
+	ar := &domain.AddressReport{Tags: []string{"heist", "phish / hack"}}
+	ar.AddProvenance("heist", &domain.Provenance{SourceURL: "https://ftmscan.com/address/0x1", ParserID: "ftmscan.com", FirstSeen: firstSeen})
+	ar.AddProvenance("phish / hack", &domain.Provenance{SourceURL: "https://ftmscan.com/address/0x1", ParserID: "ftmscan.com", FirstSeen: time.Now()})
+	state := NewReportCache(10, time.Hour)
+	state.Put("0x1", ar)
+	a := &Agent{
+		LStore: store.NewMemoryLabelStore(),
+		State:  state,
+	}
+
+	// publishing first doesn't reset the age of the labels
+	ls := []*protocol.Label{addressLabel("0x1", "exploit|heist"), addressLabel("0x1", "scam|phish / hack")}
+	a.publishLabels(ctx, "0xtx", ls, nil)
+	reasons := a.scoreLabels(ls)
+	assert.Equal(t, "0.91: tag 0.90, source ftmscan.com x0.95, category exploit x1.00, seen since "+firstSeen.UTC().Format("2006-01-02")+" +0.05", reasons["0x1"]["exploit|heist"])
+	assert.Equal(t, float32(0.91), ls[0].Confidence)
+	assert.Equal(t, "0.86: tag 0.90, source ftmscan.com x0.95, category scam x1.00", reasons["0x1"]["scam|phish / hack"])
+	assert.Equal(t, float32(0.86), ls[1].Confidence)
+}
+
+func TestLabelEvidenceFor_Conflicts(t *testing.T) {
+	ar := &domain.AddressReport{
+		Tags: []string{"phish / hack", "binance: hot wallet"},
+		Conflicts: []domain.Conflict{
+			{Source: "etherscan.io", Category: "scam", OtherSource: "bscscan.com", OtherCategory: "exchange"},
+		},
+	}
+	ev := labelEvidenceFor("etherscan.io", ar, "scam|phish / hack")
+	assert.True(t, ev.Conflicted)
+	score, reasons := scoreLabel("scam|phish / hack", ar, ev)
+	assert.Equal(t, 0.72, score)
+	assert.Contains(t, reasons, "conflicting explorers x0.80")
+
+	assert.True(t, labelEvidenceFor("etherscan.io", ar, "exchange|binance: hot wallet").Conflicted)
+	assert.False(t, labelEvidenceFor("etherscan.io", ar, "name|fake").Conflicted)
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+)
+
+// defaultAllowlists are hot infrastructure addresses per chain that never gain interesting labels
+var defaultAllowlists = map[int64][]string{
+	1: {
+		"0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", // WETH
+		"0x7a250d5630b4cf539739df2c5dacb4c659f2488d", // Uniswap V2: Router 2
+		"0xe592427a0aece92de3edee1f18e0157c05861564", // Uniswap V3: Router
+		"0x68b3465833fb72a70ecdf485e0e4c7bd8665fc45", // Uniswap V3: Router 2
+		"0x3fc91a3afd70395cd496c647d5a6cc9d4b2b7fad", // Uniswap: Universal Router
+		"0x000000000022d473030f116ddee9f6b43ac78ba3", // Uniswap: Permit2
+	},
+	56: {
+		"0xbb4cdb9cbd36b01bd1cbaebf2de08d9173bc095c", // WBNB
+		"0x10ed43c718714eb63d5aa57b78b54704e256024e", // PancakeSwap: Router v2
+		"0x13f4ea83d0bd40e75c8222255bc855a974568dd4", // PancakeSwap: Smart Router v3
+	},
+}
+
+const (
+	// precompiles are 0x01..0x09 on every chain this bot runs on
+	defaultPrecompileMax = 0x09
+	// frequency heuristics track at most this many addresses per window
+	maxTrackedAddresses = 100000
+)
+
+// AddressFilter drops addresses that can never gain interesting labels before they are scanned
+type AddressFilter struct {
+	// Allowlist holds lowercased addresses that are never scanned
+	Allowlist map[string]bool
+	// PrecompileMax is the highest precompile address; the zero address is always skipped
+	PrecompileMax uint64
+	// SkipDeployments skips contracts created by the transaction, which can't be tagged yet
+	SkipDeployments bool
+	// MaxFrequency skips addresses seen in more than MaxFrequency transactions within
+	// FrequencyWindow, since hot addresses are infrastructure rather than actors (0 disables)
+	MaxFrequency    int
+	FrequencyWindow time.Duration
+
+	mu          sync.Mutex
+	seen        map[string]int
+	windowStart time.Time
+}
+
+// NewAddressFilter returns the default filter for the chain
+func NewAddressFilter(chainID int64) *AddressFilter {
+	f := &AddressFilter{
+		Allowlist:       make(map[string]bool),
+		PrecompileMax:   defaultPrecompileMax,
+		SkipDeployments: true,
+		MaxFrequency:    50,
+		FrequencyWindow: time.Hour,
+	}
+	f.Allow(defaultAllowlists[chainID]...)
+	return f
+}
+
+// Allow adds addresses to the allowlist
+func (f *AddressFilter) Allow(addresses ...string) {
+	if f.Allowlist == nil {
+		f.Allowlist = make(map[string]bool)
+	}
+	for _, a := range addresses {
+		if a = strings.ToLower(strings.TrimSpace(a)); a != "" {
+			f.Allowlist[a] = true
+		}
+	}
+}
+
+// isPrecompile reports whether the address is the zero address or at most max
+func isPrecompile(address string, max uint64) bool {
+	hex := strings.TrimLeft(strings.TrimPrefix(strings.ToLower(address), "0x"), "0")
+	if hex == "" {
+		return true
+	}
+	if len(hex) > 16 {
+		return false
+	}
+	v, err := strconv.ParseUint(hex, 16, 64)
+	return err == nil && v <= max
+}
+
+// seenTooOften counts the address and reports whether it went over MaxFrequency in the window
+func (f *AddressFilter) seenTooOften(address string) bool {
+	if f.MaxFrequency <= 0 {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.seen == nil || time.Since(f.windowStart) > f.FrequencyWindow || len(f.seen) >= maxTrackedAddresses {
+		f.seen = make(map[string]int)
+		f.windowStart = time.Now()
+	}
+	f.seen[address]++
+	return f.seen[address] > f.MaxFrequency
+}
+
+// Skip reports whether the address of the transaction should not be scanned, and why
+func (f *AddressFilter) Skip(address string, ev *protocol.TransactionEvent) (bool, string) {
+	address = strings.ToLower(address)
+	if isPrecompile(address, f.PrecompileMax) {
+		return true, "precompile"
+	}
+	if f.Allowlist[address] {
+		return true, "allowlist"
+	}
+	if f.SkipDeployments && ev != nil && ev.IsContractDeployment && strings.EqualFold(ev.ContractAddress, address) {
+		return true, "deployment"
+	}
+	if f.seenTooOften(address) {
+		return true, "frequency"
+	}
+	return false, ""
+}
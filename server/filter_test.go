@@ -0,0 +1,46 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressFilter_Skip(t *testing.T) {
+	f := NewAddressFilter(1)
+	f.Allow(" 0xDEAD000000000000000000000000000000000000 ")
+	tx := &protocol.TransactionEvent{IsContractDeployment: true, ContractAddress: "0x00000000000000000000000000000000000000c1"}
+
+	tests := map[string]string{
+		"0x0000000000000000000000000000000000000000": "precompile",
+		"0x0000000000000000000000000000000000000009": "precompile",
+		"0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2": "allowlist",
+		"0xdead000000000000000000000000000000000000": "allowlist",
+		"0x00000000000000000000000000000000000000c1": "deployment",
+		"0x000000000000000000000000000000000000000a": "",
+		"0x4d30774eba5421e79626e747948505fd280e4ac0": "",
+	}
+	for address, expected := range tests {
+		skip, reason := f.Skip(address, tx)
+		assert.Equal(t, expected != "", skip, address)
+		assert.Equal(t, expected, reason, address)
+	}
+}
+
+func TestAddressFilter_Frequency(t *testing.T) {
+	f := &AddressFilter{MaxFrequency: 2, FrequencyWindow: time.Hour}
+	for i := 0; i < 2; i++ {
+		skip, _ := f.Skip("0x1111111111111111111111111111111111111111", nil)
+		assert.False(t, skip)
+	}
+	skip, reason := f.Skip("0x1111111111111111111111111111111111111111", nil)
+	assert.True(t, skip)
+	assert.Equal(t, "frequency", reason)
+
+	// a new window starts over
+	f.windowStart = time.Now().Add(-2 * time.Hour)
+	skip, _ = f.Skip("0x1111111111111111111111111111111111111111", nil)
+	assert.False(t, skip)
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"golang.org/x/exp/slices"
+
+	"forta-network/go-agent/domain"
+	"forta-network/go-agent/taxonomy"
+)
+
+func addressLabel(address, label string) *protocol.Label {
+	return &protocol.Label{
+		EntityType: protocol.Label_ADDRESS,
+		Entity:     address,
+		Confidence: 1,
+		Label:      label,
+	}
+}
+
+// prefixedLabel builds "<prefix>|<value>" labels, keeping the separator out of the value
+func prefixedLabel(prefix, value string) string {
+	return fmt.Sprintf("%s|%s", prefix, strings.ReplaceAll(value, "|", "_"))
+}
+
+// categoryLabel builds "<category>|<tag>" labels so consumers can filter on the category
+func categoryLabel(tag string) string {
+	return prefixedLabel(string(taxonomy.Classify(tag)), strings.ToLower(tag))
+}
+
+// reportLabels converts an address report into the labels published for the address; they are
+// scored by scoreLabels once they are about to be published
+func reportLabels(address string, ar *domain.AddressReport) []*protocol.Label {
+	var result []*protocol.Label
+	for _, t := range ar.Tags {
+		result = append(result, addressLabel(address, categoryLabel(t)))
+	}
+	if ar.Name != "" {
+		result = append(result, addressLabel(address, prefixedLabel("name", ar.Name)))
+		// names such as "fake_phishing5814" are often the only hint of what the address is
+		if c := taxonomy.Classify(ar.Name); c != taxonomy.Other && !slices.Contains(ar.Tags, ar.Name) {
+			result = append(result, addressLabel(address, categoryLabel(ar.Name)))
+		}
+	}
+	if ar.ContractName != "" {
+		result = append(result, addressLabel(address, prefixedLabel("contract", ar.ContractName)))
+	}
+	if ar.Implementation != "" {
+		result = append(result, addressLabel(address, prefixedLabel("proxy", ar.Implementation)))
+	}
+	if ar.Reputation != "" {
+		result = append(result, addressLabel(address, prefixedLabel("reputation", strings.ToLower(ar.Reputation))))
+	}
+	for _, w := range ar.Warnings {
+		result = append(result, addressLabel(address, prefixedLabel("warning", strings.ToLower(w))))
+	}
+	return result
+}
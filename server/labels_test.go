@@ -0,0 +1,50 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"forta-network/go-agent/domain"
+)
+
+func TestReportLabels(t *testing.T) {
+	ls := reportLabels("0x1", &domain.AddressReport{
+		Name:       "fake usdt",
+		Tags:       []string{"Phish / Hack"},
+		Reputation: "Unsafe",
+		Warnings:   []string{"reported for phishing | scam"},
+	})
+	var labels []string
+	for _, l := range ls {
+		assert.Equal(t, "0x1", l.Entity)
+		labels = append(labels, l.Label)
+	}
+	assert.Equal(t, []string{"scam|phish / hack", "name|fake usdt", "scam|fake usdt", "reputation|unsafe", "warning|reported for phishing _ scam"}, labels)
+}
+
+func TestReportLabels_Categories(t *testing.T) {
+	ls := reportLabels("0x1", &domain.AddressReport{
+		Name: "yearn (ydai) exploiter",
+		Tags: []string{"Heist", "Bitfinex"},
+	})
+	var labels []string
+	for _, l := range ls {
+		labels = append(labels, l.Label)
+	}
+	assert.Equal(t, []string{"exploit|heist", "other|bitfinex", "name|yearn (ydai) exploiter", "exploit|yearn (ydai) exploiter"}, labels)
+}
+
+func TestReportLabels_Contract(t *testing.T) {
+	ls := reportLabels("0x1", &domain.AddressReport{
+		ContractName:   "tokensale",
+		Implementation: "0xabc",
+	})
+	var labels []string
+	for _, l := range ls {
+		labels = append(labels, l.Label)
+	}
+	assert.Equal(t, []string{"contract|tokensale", "proxy|0xabc"}, labels)
+	score, _ := scoreLabel("proxy|0xabc", nil, labelEvidence{Source: "etherscan.io", Sources: 1})
+	assert.Equal(t, 0.9, score)
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultQueueDepth   = 1000
+	defaultQueueWorkers = 4
+	queuedScanTimeout   = 2 * time.Minute
+)
+
+// queuedAddress is an address waiting to be scanned and the transaction it was seen in
+type queuedAddress struct {
+	address string
+	txHash  string
+}
+
+// scanQueue holds the addresses waiting to be scanned in the background, each at most once
+type scanQueue struct {
+	addresses chan queuedAddress
+	mu        sync.Mutex
+	inFlight  map[string]bool
+}
+
+func newScanQueue(depth int) *scanQueue {
+	return &scanQueue{
+		addresses: make(chan queuedAddress, depth),
+		inFlight:  make(map[string]bool),
+	}
+}
+
+// enqueue adds the address unless it is already queued or being scanned, or the queue is full
+func (q *scanQueue) enqueue(address, txHash string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.inFlight[address] {
+		return false
+	}
+	select {
+	case q.addresses <- queuedAddress{address: address, txHash: txHash}:
+		q.inFlight[address] = true
+		return true
+	default:
+		log.WithField("entity", address).Warn("scan queue is full (dropping address)")
+		return false
+	}
+}
+
+// done allows the address to be queued again
+func (q *scanQueue) done(address string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.inFlight, address)
+}
+
+func (q *scanQueue) depth() int {
+	return len(q.addresses)
+}
+
+// startQueue starts the background scan workers once
+func (a *Agent) startQueue() {
+	a.queueOnce.Do(func() {
+		depth := a.QueueDepth
+		if depth <= 0 {
+			depth = defaultQueueDepth
+		}
+		workers := a.QueueWorkers
+		if workers <= 0 {
+			workers = defaultQueueWorkers
+		}
+		a.queue = newScanQueue(depth)
+		for i := 0; i < workers; i++ {
+			go func() {
+				for qa := range a.queue.addresses {
+					a.scanQueued(qa.address, qa.txHash)
+					a.queue.done(qa.address)
+				}
+			}()
+		}
+	})
+}
+
+// scanQueued scans one queued address and holds its label changes for a later response; they
+// are stored once the response carries them
+func (a *Agent) scanQueued(address, txHash string) {
+	ctx, cancel := context.WithTimeout(context.Background(), queuedScanTimeout)
+	defer cancel()
+	ar, d := a.checkAddress(ctx, address)
+	if ar == nil {
+		return
+	}
+	labels, removed := a.changedLabels(ctx, address, ar, d, d != nil)
+	newLabels, duplicates := a.filterOutDuplicates(ctx, labels)
+	a.Mux.Lock()
+	defer a.Mux.Unlock()
+	if a.queuedChanges == nil {
+		a.queuedChanges = &labelChanges{txHashes: make(map[string]string)}
+	}
+	// the address can be scanned again before a response takes its changes
+	c := a.queuedChanges
+	c.add(address, notHeld(c.added, newLabels), notHeld(c.removed, removed), d)
+	c.duplicates = append(c.duplicates, notHeld(c.duplicates, duplicates)...)
+	if _, ok := c.txHashes[address]; !ok {
+		c.txHashes[address] = txHash
+	}
+}
+
+// notHeld returns the labels that aren't already in held, by entity and label
+func notHeld(held, ls []*protocol.Label) []*protocol.Label {
+	var result []*protocol.Label
+	for _, l := range ls {
+		found := false
+		for _, h := range held {
+			if h.Entity == l.Entity && h.Label == l.Label {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+// takeQueuedFinding stores and returns a finding for the label changes of the background scans
+// done since the last call, nil if there are none
+func (a *Agent) takeQueuedFinding(ctx context.Context) *protocol.Finding {
+	a.Mux.Lock()
+	changes := a.queuedChanges
+	a.queuedChanges = nil
+	a.Mux.Unlock()
+	if changes == nil || (len(changes.added) == 0 && len(changes.removed) == 0) {
+		return nil
+	}
+	log.WithFields(log.Fields{
+		"labels":  len(changes.added),
+		"removed": len(changes.removed),
+	}).Info("returning queued finding")
+	f := a.labelFinding("label-sync", "Syncing Labels", changes)
+	for _, l := range changes.added {
+		a.publishLabels(ctx, changes.txHashes[l.Entity], []*protocol.Label{l}, nil)
+	}
+	a.deleteRemovedLabels(ctx, changes.removed)
+	return f
+}
+
+// enqueueTx queues the addresses of the transaction and returns the findings of earlier scans
+func (a *Agent) enqueueTx(ctx context.Context, request *protocol.EvaluateTxRequest) *protocol.EvaluateTxResponse {
+	a.startQueue()
+	queued := 0
+	for address := range request.Event.Addresses {
+		if a.skipAddress(address, request.Event) {
+			continue
+		}
+		if a.queue.enqueue(address, request.Event.Transaction.GetHash()) {
+			queued++
+		}
+	}
+	log.WithFields(log.Fields{
+		"tx":     request.Event.Transaction.GetHash(),
+		"queued": queued,
+		"depth":  a.queue.depth(),
+	}).Debug("queued addresses")
+
+	resp := &protocol.EvaluateTxResponse{
+		Status:    protocol.ResponseStatus_SUCCESS,
+		Findings:  []*protocol.Finding{},
+		Metadata:  map[string]string{},
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+	if f := a.takeQueuedFinding(ctx); f != nil {
+		resp.Findings = append(resp.Findings, f)
+	}
+	return resp
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/stretchr/testify/assert"
+
+	"forta-network/go-agent/store"
+)
+
+func TestScanQueue(t *testing.T) {
+	q := newScanQueue(1)
+	assert.True(t, q.enqueue("0x1", "0xtx1"))
+	assert.False(t, q.enqueue("0x1", "0xtx2"), "already queued")
+	assert.False(t, q.enqueue("0x2", "0xtx2"), "queue is full")
+
+	assert.Equal(t, queuedAddress{address: "0x1", txHash: "0xtx1"}, <-q.addresses)
+	assert.False(t, q.enqueue("0x1", "0xtx2"), "still being scanned")
+	q.done("0x1")
+	assert.True(t, q.enqueue("0x1", "0xtx2"))
+}
+
+func TestAgent_AsyncScans(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "heist")
+	}))
+	defer srv.Close()
+
+	ls := &fakeLabelStore{}
+	a := &Agent{
+		Parser:     &bodyParser{pattern: srv.URL + "/address/%s"},
+		LStore:     ls,
+		LabelAPI:   &fakeLabelAPI{},
+		AsyncScans: true,
+		Filter:     NewAddressFilter(1),
+	}
+	resp, err := a.EvaluateTx(context.Background(), &protocol.EvaluateTxRequest{
+		Event: &protocol.TransactionEvent{
+			Transaction: &protocol.TransactionEvent_EthTransaction{Hash: "0xabc"},
+			Addresses: map[string]bool{
+				"0x1111111111111111111111111111111111111111": true,
+				"0x0000000000000000000000000000000000000001": true,
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, resp.Findings, "scans don't hold up the response")
+
+	assert.Eventually(t, func() bool {
+		a.Mux.Lock()
+		defer a.Mux.Unlock()
+		return a.queuedChanges != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, ls.put, "labels are stored once a response carries them")
+
+	var findings []*protocol.Finding
+	assert.Eventually(t, func() bool {
+		resp, err := a.EvaluateBlock(context.Background(), &protocol.EvaluateBlockRequest{})
+		assert.NoError(t, err)
+		for _, f := range resp.Findings {
+			if f.AlertId == "label-sync" {
+				findings = append(findings, f)
+			}
+		}
+		return len(findings) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Len(t, findings, 1)
+	assert.Equal(t, `{"0x1111111111111111111111111111111111111111":"exploit|heist"}`, findings[0].Metadata["added"])
+	assert.Equal(t, []string{"exploit|heist"}, ls.put)
+	assert.Equal(t, []store.LabelSource{{
+		TxHash:    "0xabc",
+		SourceURL: srv.URL + "/address/0x1111111111111111111111111111111111111111",
+	}}, ls.sources)
+}
+
+func TestAgent_ScanQueuedTwice(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "heist")
+	}))
+	defer srv.Close()
+
+	ls := &fakeLabelStore{}
+	a := &Agent{
+		Parser:   &bodyParser{pattern: srv.URL + "/address/%s"},
+		LStore:   ls,
+		LabelAPI: &fakeLabelAPI{},
+	}
+	// the address is queued again once its first scan is done, before a response takes it
+	address := "0x1111111111111111111111111111111111111111"
+	a.scanQueued(address, "0xtx1")
+	a.scanQueued(address, "0xtx2")
+
+	f := a.takeQueuedFinding(context.Background())
+	assert.NotNil(t, f)
+	assert.Len(t, f.Labels, 1)
+	assert.Equal(t, []string{"exploit|heist"}, ls.put)
+	assert.Equal(t, "0xtx1", ls.sources[0].TxHash)
+}
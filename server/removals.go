@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+
+	label_api "forta-network/go-agent/label-api"
+)
+
+func (a *Agent) labelAPI() label_api.Client {
+	if a.LabelAPI != nil {
+		return a.LabelAPI
+	}
+	return label_api.NewClient(nil)
+}
+
+// publishedLabels returns the labels this bot has published for the entity on this chain. The
+// label api isn't asked: it returns the labels of every chain the bot runs on, and a removal
+// retracts a label on all of them.
+func (a *Agent) publishedLabels(ctx context.Context, entity string) ([]string, error) {
+	var result []string
+	cached, err := a.LStore.ListEntityLabels(ctx, entity)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range cached {
+		if !slices.Contains(result, l.Label) {
+			result = append(result, l.Label)
+		}
+	}
+	return result, nil
+}
+
+// removedLabels returns removal labels for everything published for the entity that a re-scan no longer yields
+func (a *Agent) removedLabels(ctx context.Context, entity string, current []*protocol.Label) []*protocol.Label {
+	// an empty re-scan is far more likely a markup change than every tag being retracted at once
+	if len(current) == 0 {
+		log.WithField("entity", entity).Warn("re-scan found no labels, not removing any")
+		return nil
+	}
+	published, err := a.publishedLabels(ctx, entity)
+	if err != nil {
+		log.WithError(err).WithField("entity", entity).Error("error listing published labels (skipping removals)")
+		return nil
+	}
+	var result []*protocol.Label
+	for _, p := range published {
+		if slices.IndexFunc(current, func(l *protocol.Label) bool { return sameLabel(p, l.Label) }) >= 0 {
+			continue
+		}
+		l := addressLabel(entity, p)
+		l.Remove = true
+		result = append(result, l)
+	}
+	return result
+}
+
+// sameLabel reports whether a published label is the current one. Tags published before they
+// were classified went out bare, and count as their "<category>|<tag>" label.
+func sameLabel(published, current string) bool {
+	if strings.EqualFold(published, current) {
+		return true
+	}
+	return !strings.Contains(published, "|") && strings.EqualFold(categoryLabel(published), current)
+}
+
+// deleteRemovedLabels drops the removed labels from the cache so they can be re-added later
+func (a *Agent) deleteRemovedLabels(ctx context.Context, removals []*protocol.Label) {
+	for _, l := range removals {
+		if err := a.LStore.DeleteLabel(ctx, l.Entity, l.Label); err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"label":  l.Label,
+				"entity": l.Entity,
+			}).Error("error deleting removed label from cache (ignoring)")
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/slices"
+
+	"forta-network/go-agent/domain"
+	label_api "forta-network/go-agent/label-api"
+	"forta-network/go-agent/store"
+)
+
+type fakeLabelStore struct {
+	store.LabelStore
+	labels  []*store.Label
+	deleted []string
+	put     []string
+	sources []store.LabelSource
+}
+
+func (s *fakeLabelStore) ListEntityLabels(ctx context.Context, entity string) ([]*store.Label, error) {
+	return s.labels, nil
+}
+
+func (s *fakeLabelStore) ListEntities(ctx context.Context, cursor string, limit int) ([]string, string, error) {
+	var result []string
+	for _, l := range s.labels {
+		if !slices.Contains(result, l.Entity) {
+			result = append(result, l.Entity)
+		}
+	}
+	return result, "", nil
+}
+
+func (s *fakeLabelStore) EntityExists(ctx context.Context, entity string) (bool, error) {
+	return false, nil
+}
+
+func (s *fakeLabelStore) GetLabel(ctx context.Context, entity, label string) (*store.Label, error) {
+	return nil, nil
+}
+
+func (s *fakeLabelStore) GetLabels(ctx context.Context, keys []store.EntityLabel) ([]*store.Label, error) {
+	return make([]*store.Label, len(keys)), nil
+}
+
+func (s *fakeLabelStore) PutLabel(ctx context.Context, entity, label string, src store.LabelSource) error {
+	s.put = append(s.put, label)
+	s.sources = append(s.sources, src)
+	return nil
+}
+
+func (s *fakeLabelStore) DeleteLabel(ctx context.Context, entity, label string) error {
+	s.deleted = append(s.deleted, label)
+	return nil
+}
+
+type fakeLabelAPI struct {
+	labels []*protocol.Label
+}
+
+func (c *fakeLabelAPI) GetLabels(req *label_api.GetLabelsRequest) ([]*protocol.Label, error) {
+	var result []*protocol.Label
+	for _, l := range c.labels {
+		if len(req.Labels) == 0 || slices.Contains(req.Labels, l.Label) {
+			result = append(result, l)
+		}
+	}
+	return result, nil
+}
+
+func TestAgent_RemovedLabels(t *testing.T) {
+	ls := &fakeLabelStore{labels: []*store.Label{{Entity: "0x1", Label: "heist"}, {Entity: "0x1", Label: "name|exploiter"}}}
+	a := &Agent{
+		LStore: ls,
+		// labels the bot published on other chains
+		LabelAPI: &fakeLabelAPI{labels: []*protocol.Label{addressLabel("0x1", "blocked")}},
+	}
+
+	removed := a.removedLabels(context.Background(), "0x1", []*protocol.Label{addressLabel("0x1", "heist")})
+	var labels []string
+	for _, l := range removed {
+		assert.True(t, l.Remove)
+		assert.Equal(t, "0x1", l.Entity)
+		labels = append(labels, l.Label)
+	}
+	// labels of other chains are left alone
+	assert.Equal(t, []string{"name|exploiter"}, labels)
+
+	a.deleteRemovedLabels(context.Background(), removed)
+	assert.Equal(t, []string{"name|exploiter"}, ls.deleted)
+
+	// bare tags published before tags were classified are not removed while the tag is still shown
+	removed = a.removedLabels(context.Background(), "0x1", []*protocol.Label{addressLabel("0x1", "exploit|heist")})
+	assert.Len(t, removed, 1)
+	assert.Equal(t, "name|exploiter", removed[0].Label)
+
+	// an empty re-scan removes nothing
+	assert.Empty(t, a.removedLabels(context.Background(), "0x1", nil))
+}
+
+func TestAgent_ChangedLabels(t *testing.T) {
+	ls := &fakeLabelStore{labels: []*store.Label{{Entity: "0x1", Label: "exploit|heist"}}}
+	a := &Agent{LStore: ls, LabelAPI: &fakeLabelAPI{}}
+	ar := &domain.AddressReport{Tags: []string{"phish / hack"}}
+
+	// first scan, nothing published before
+	_, removed := a.changedLabels(context.Background(), "0x1", ar, nil, false)
+	assert.Empty(t, removed)
+
+	// an unchanged re-scan doesn't look up published labels
+	_, removed = a.changedLabels(context.Background(), "0x1", ar, &domain.ReportDiff{}, true)
+	assert.Empty(t, removed)
+
+	labels, removed := a.changedLabels(context.Background(), "0x1", ar, &domain.ReportDiff{RemovedTags: []string{"heist"}}, true)
+	assert.Len(t, labels, 1)
+	assert.Len(t, removed, 1)
+	assert.Equal(t, "exploit|heist", removed[0].Label)
+
+	// a reputation change alone retracts the previous reputation
+	ls.labels = []*store.Label{{Entity: "0x1", Label: "scam|phish / hack"}, {Entity: "0x1", Label: "reputation|neutral"}}
+	ar = &domain.AddressReport{Tags: []string{"phish / hack"}, Reputation: "Unsafe"}
+	_, removed = a.changedLabels(context.Background(), "0x1", ar, domain.Diff(&domain.AddressReport{Tags: ar.Tags, Reputation: "Neutral"}, ar), true)
+	assert.Len(t, removed, 1)
+	assert.Equal(t, "reputation|neutral", removed[0].Label)
+
+	// nothing is removed when an explorer couldn't be scanned
+	ar.Partial = true
+	_, removed = a.changedLabels(context.Background(), "0x1", ar, nil, true)
+	assert.Empty(t, removed)
+}
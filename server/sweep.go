@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultSweepInterval  = 10 * time.Minute
+	defaultSweepBatchSize = 25
+	sweepTimeout          = 5 * time.Minute
+	// reports fresher than this were just scanned for a transaction and are not re-scanned
+	sweepMinAge = time.Hour
+)
+
+// sweepDue reports whether the next batch of stored entities should be re-verified, and marks
+// the sweep as running if so
+func (a *Agent) sweepDue() bool {
+	interval := a.SweepInterval
+	if interval == 0 {
+		interval = defaultSweepInterval
+	}
+	a.Mux.Lock()
+	defer a.Mux.Unlock()
+	if a.Parser == nil || interval < 0 || a.sweeping || time.Since(a.lastSweep) < interval {
+		return false
+	}
+	a.sweeping = true
+	a.lastSweep = time.Now()
+	return true
+}
+
+// sweep re-scans the next batch of stored entities and queues a finding for the labels
+// that were added or removed since they were published
+func (a *Agent) sweep() {
+	ctx, cancel := context.WithTimeout(context.Background(), sweepTimeout)
+	defer cancel()
+	defer func() {
+		a.Mux.Lock()
+		a.sweeping = false
+		a.Mux.Unlock()
+	}()
+
+	batchSize := a.SweepBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultSweepBatchSize
+	}
+	a.Mux.Lock()
+	cursor := a.sweepCursor
+	a.Mux.Unlock()
+
+	entities, next, err := a.LStore.ListEntities(ctx, cursor, batchSize)
+	if err != nil {
+		log.WithError(err).Error("error listing stored entities (skipping sweep)")
+		return
+	}
+
+	changes := &labelChanges{}
+	for _, entity := range entities {
+		if ctx.Err() != nil {
+			// the cursor isn't advanced, so the batch is retried next time
+			log.WithError(ctx.Err()).Warn("sweep timed out")
+			return
+		}
+		s, ok := a.state().Get(entity)
+		if ok && time.Since(s.LastChecked) < sweepMinAge {
+			continue
+		}
+		ar, d := a.scanAddress(ctx, entity)
+		if ar == nil {
+			continue
+		}
+		// stored entities have published labels whether or not a previous report is known
+		labels, removed := a.changedLabels(ctx, entity, ar, d, true)
+		changes.add(entity, labels, removed, d)
+	}
+
+	a.Mux.Lock()
+	a.sweepCursor = next
+	a.Mux.Unlock()
+
+	changes.added, changes.duplicates = a.filterOutDuplicates(ctx, changes.added)
+	newLabels, removals := changes.added, changes.removed
+	log.WithFields(log.Fields{
+		"entities": len(entities),
+		"labels":   len(newLabels),
+		"removed":  len(removals),
+		"wrapped":  next == "",
+	}).Info("swept stored entities")
+	if len(newLabels) == 0 && len(removals) == 0 {
+		return
+	}
+	f := a.labelFinding("label-sweep", "Re-verifying Labels", changes)
+	a.publishLabels(ctx, "", newLabels, removals)
+	a.Mux.Lock()
+	a.pendingFindings = append(a.pendingFindings, f)
+	a.Mux.Unlock()
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"forta-network/go-agent/domain"
+	"forta-network/go-agent/store"
+)
+
+// bodyParser reads every line of the page as a tag
+type bodyParser struct {
+	pattern string
+}
+
+func (p *bodyParser) ExtractName(body string) string { return "" }
+
+func (p *bodyParser) ExtractTags(body string) []string {
+	return strings.Split(strings.TrimSpace(body), "\n")
+}
+
+func (p *bodyParser) URLPatterns() []string { return []string{p.pattern} }
+
+func TestAgent_Sweep(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "phish / hack")
+	}))
+	defer srv.Close()
+
+	ls := &fakeLabelStore{labels: []*store.Label{
+		{Entity: "0x1", Label: "heist"},
+		{Entity: "0x2", Label: "phish / hack"},
+	}}
+	state := NewReportCache(10, time.Hour)
+	state.Put("0x1", &domain.AddressReport{Tags: []string{"heist"}, LastChecked: time.Now().Add(-2 * time.Hour)})
+	// scanned for a transaction moments ago
+	state.Put("0x2", &domain.AddressReport{Tags: []string{"phish / hack"}, LastChecked: time.Now()})
+	a := &Agent{
+		Parser:   &bodyParser{pattern: srv.URL + "/address/%s"},
+		LStore:   ls,
+		LabelAPI: &fakeLabelAPI{},
+		State:    state,
+	}
+
+	assert.True(t, a.sweepDue())
+	assert.False(t, a.sweepDue(), "a sweep is already running")
+	a.sweep()
+	assert.False(t, a.sweepDue(), "the interval hasn't passed")
+
+	findings := a.takePendingFindings()
+	assert.Len(t, findings, 1)
+	assert.Equal(t, "label-sweep", findings[0].AlertId)
+	assert.Equal(t, `{"0x1":"scam|phish / hack"}`, findings[0].Metadata["added"])
+	assert.Equal(t, `{"0x1":"heist"}`, findings[0].Metadata["removed"])
+	assert.Equal(t, `{"0x1":{"scam|phish / hack":["`+srv.URL+`/address/0x1"]}}`, findings[0].Metadata["sources"])
+	assert.Equal(t, `{"0x1":{"addedTags":["phish / hack"],"removedTags":["heist"]}}`, findings[0].Metadata["changes"])
+	assert.Equal(t, []string{"scam|phish / hack"}, ls.put)
+	assert.Equal(t, []string{"heist"}, ls.deleted)
+}
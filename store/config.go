@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// backends a label store can be opened with
+const (
+	BackendDynamoDB = "dynamodb"
+	BackendMemory   = "memory"
+	BackendFile     = "file"
+)
+
+// Config selects the label store backend
+type Config struct {
+	// Backend is one of BackendDynamoDB (the default), BackendMemory or BackendFile
+	Backend string
+	// File is where BackendFile keeps its labels
+	File string
+
+	// Table, Region and Endpoint locate the BackendDynamoDB table; Endpoint is only set for
+	// emulators such as DynamoDB Local
+	Table    string
+	Region   string
+	Endpoint string
+	// TTL expires labels this long after they were last published or removed, through the
+	// table's expiresAt TTL attribute (0 keeps them forever)
+	TTL time.Duration
+	// KeyPrefix replaces the "[<chainId>|]<botId>|etherscan-labels|" prefix of item ids
+	KeyPrefix string
+}
+
+const (
+	defaultTable  = "prod-research-bot-data"
+	defaultRegion = "us-east-1"
+)
+
+// ConfigFromEnv reads the store config from LABEL_STORE, LABEL_STORE_FILE, LABEL_STORE_TABLE,
+// LABEL_STORE_REGION, LABEL_STORE_ENDPOINT, LABEL_STORE_TTL and LABEL_STORE_KEY_PREFIX
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Backend:   os.Getenv("LABEL_STORE"),
+		File:      os.Getenv("LABEL_STORE_FILE"),
+		Table:     os.Getenv("LABEL_STORE_TABLE"),
+		Region:    os.Getenv("LABEL_STORE_REGION"),
+		Endpoint:  os.Getenv("LABEL_STORE_ENDPOINT"),
+		KeyPrefix: os.Getenv("LABEL_STORE_KEY_PREFIX"),
+	}
+	if v := os.Getenv("LABEL_STORE_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to parse label store ttl: %s: %w", v, err)
+		}
+		cfg.TTL = ttl
+	}
+	return cfg, nil
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Table == "" {
+		cfg.Table = defaultTable
+	}
+	if cfg.Region == "" {
+		cfg.Region = defaultRegion
+	}
+	return cfg
+}
+
+// NeedsSecrets reports whether the store needs the AWS credentials in the bot secrets
+func (cfg Config) NeedsSecrets() bool {
+	return (cfg.Backend == "" || cfg.Backend == BackendDynamoDB) && cfg.Endpoint == ""
+}
+
+// Open returns the label store of the configured backend; secrets are only needed for DynamoDB
+func Open(ctx context.Context, cfg Config, chainID int64, botID string, secrets *Secrets) (LabelStore, error) {
+	switch cfg.Backend {
+	case "", BackendDynamoDB:
+		return newDynamoLabelStore(ctx, cfg, chainID, botID, secrets)
+	case BackendMemory:
+		return NewMemoryLabelStore(), nil
+	case BackendFile:
+		if cfg.File == "" {
+			return nil, fmt.Errorf("file label store needs a file")
+		}
+		return NewFileLabelStore(cfg.File)
+	}
+	return nil, fmt.Errorf("unknown label store backend: %s", cfg.Backend)
+}
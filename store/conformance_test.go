@@ -0,0 +1,175 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testConformance runs the behaviour every LabelStore backend must share
+func testConformance(t *testing.T, newStore func(t *testing.T) LabelStore) {
+	ctx := context.Background()
+
+	t.Run("PutGet", func(t *testing.T) {
+		s := newStore(t)
+		exists, err := s.EntityExists(ctx, "0xabc")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+		l, err := s.GetLabel(ctx, "0xabc", "exploit|heist")
+		assert.NoError(t, err)
+		assert.Nil(t, l)
+
+		assert.NoError(t, s.PutLabel(ctx, "0xABC", " Exploit|Heist ", LabelSource{}))
+		// putting the same label again is a no-op
+		assert.NoError(t, s.PutLabel(ctx, "0xabc", "exploit|heist", LabelSource{}))
+
+		exists, err = s.EntityExists(ctx, "0xabc")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		l, err = s.GetLabel(ctx, "0xAbc", "EXPLOIT|HEIST")
+		assert.NoError(t, err)
+		if assert.NotNil(t, l) {
+			assert.Equal(t, "0xabc", l.Entity)
+			assert.Equal(t, "exploit|heist", l.Label)
+		}
+		l, err = s.GetLabel(ctx, "0xabc", "scam|phish / hack")
+		assert.NoError(t, err)
+		assert.Nil(t, l)
+		exists, err = s.EntityExists(ctx, "0xdef")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("GetLabels", func(t *testing.T) {
+		s := newStore(t)
+		assert.NoError(t, s.PutLabel(ctx, "0xabc", "exploit|heist", LabelSource{}))
+		assert.NoError(t, s.PutLabel(ctx, "0xdef", "scam|phish / hack", LabelSource{}))
+
+		ls, err := s.GetLabels(ctx, []EntityLabel{
+			{Entity: "0xabc", Label: "exploit|heist"},
+			{Entity: "0xabc", Label: "scam|phish / hack"},
+			{Entity: "0xDEF", Label: "Scam|Phish / Hack"},
+			{Entity: "0xabc", Label: "exploit|heist"},
+		})
+		assert.NoError(t, err)
+		if assert.Len(t, ls, 4) {
+			assert.Equal(t, "exploit|heist", ls[0].Label)
+			assert.Nil(t, ls[1])
+			assert.Equal(t, "0xdef", ls[2].Entity)
+			assert.Equal(t, "exploit|heist", ls[3].Label)
+		}
+
+		ls, err = s.GetLabels(ctx, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, ls)
+	})
+
+	t.Run("ListDelete", func(t *testing.T) {
+		s := newStore(t)
+		assert.NoError(t, s.PutLabel(ctx, "0xabc", "exploit|heist", LabelSource{}))
+		assert.NoError(t, s.PutLabel(ctx, "0xabc", "name|exploiter", LabelSource{}))
+		assert.NoError(t, s.PutLabel(ctx, "0xdef", "scam|phish / hack", LabelSource{}))
+
+		ls, err := s.ListEntityLabels(ctx, "0xabc")
+		assert.NoError(t, err)
+		var labels []string
+		for _, l := range ls {
+			assert.Equal(t, "0xabc", l.Entity)
+			labels = append(labels, l.Label)
+		}
+		assert.ElementsMatch(t, []string{"exploit|heist", "name|exploiter"}, labels)
+
+		assert.NoError(t, s.DeleteLabel(ctx, "0xabc", "exploit|heist"))
+		assert.NoError(t, s.DeleteLabel(ctx, "0xabc", "name|exploiter"))
+		// deleting a missing label is not an error
+		assert.NoError(t, s.DeleteLabel(ctx, "0xabc", "name|exploiter"))
+		exists, err := s.EntityExists(ctx, "0xabc")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+		ls, err = s.ListEntityLabels(ctx, "0xabc")
+		assert.NoError(t, err)
+		assert.Empty(t, ls)
+	})
+
+	t.Run("ListEntities", func(t *testing.T) {
+		s := newStore(t)
+		expected := []string{"0x1", "0x2", "0x3", "0x4", "0x5"}
+		for _, e := range expected {
+			assert.NoError(t, s.PutLabel(ctx, e, "exploit|heist", LabelSource{}))
+			assert.NoError(t, s.PutLabel(ctx, e, "name|exploiter", LabelSource{}))
+		}
+		var entities []string
+		cursor := ""
+		for i := 0; i < 10; i++ {
+			batch, next, err := s.ListEntities(ctx, cursor, 2)
+			assert.NoError(t, err)
+			entities = append(entities, batch...)
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+		// every entity is listed exactly once
+		assert.Equal(t, expected, entities)
+
+		entities, next, err := s.ListEntities(ctx, "", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, entities)
+		assert.Empty(t, next)
+	})
+	t.Run("History", func(t *testing.T) {
+		s := newStore(t)
+		src := LabelSource{TxHash: "0xtx1", SourceURL: "https://etherscan.io/address/0xabc"}
+		assert.NoError(t, s.PutLabel(ctx, "0xabc", "exploit|heist", src))
+		l, err := s.GetLabel(ctx, "0xabc", "exploit|heist")
+		assert.NoError(t, err)
+		if !assert.NotNil(t, l) {
+			return
+		}
+		created := l.CreatedAt
+		assert.False(t, created.IsZero())
+		assert.Equal(t, created, l.UpdatedAt)
+		assert.Equal(t, "0xtx1", l.TxHash)
+		assert.Equal(t, src.SourceURL, l.SourceURL)
+
+		// publishing again keeps when and where the label was first seen
+		time.Sleep(time.Second)
+		assert.NoError(t, s.PutLabel(ctx, "0xabc", "exploit|heist", LabelSource{TxHash: "0xtx2"}))
+		assert.NoError(t, s.PutLabel(ctx, "0xabc", "name|exploiter", LabelSource{TxHash: "0xtx2"}))
+		l, err = s.GetLabel(ctx, "0xabc", "exploit|heist")
+		assert.NoError(t, err)
+		assert.True(t, l.CreatedAt.Equal(created))
+		assert.True(t, l.UpdatedAt.After(created))
+		assert.Equal(t, "0xtx1", l.TxHash)
+
+		assert.NoError(t, s.DeleteLabel(ctx, "0xabc", "exploit|heist"))
+		ls, err := s.ListEntityLabels(ctx, "0xabc")
+		assert.NoError(t, err)
+		assert.Len(t, ls, 1)
+
+		// the history keeps removed labels, oldest first
+		ls, err = s.ListLabels(ctx, "0xABC")
+		assert.NoError(t, err)
+		if assert.Len(t, ls, 2) {
+			assert.Equal(t, "exploit|heist", ls[0].Label)
+			assert.NotNil(t, ls[0].RemovedAt)
+			assert.Equal(t, "name|exploiter", ls[1].Label)
+			assert.Nil(t, ls[1].RemovedAt)
+		}
+
+		// publishing a removed label restores it
+		assert.NoError(t, s.PutLabel(ctx, "0xabc", "exploit|heist", LabelSource{}))
+		l, err = s.GetLabel(ctx, "0xabc", "exploit|heist")
+		assert.NoError(t, err)
+		if assert.NotNil(t, l) {
+			assert.Nil(t, l.RemovedAt)
+			assert.True(t, l.CreatedAt.Equal(created))
+		}
+
+		ls, err = s.ListLabels(ctx, "0xdef")
+		assert.NoError(t, err)
+		assert.Empty(t, ls)
+	})
+}
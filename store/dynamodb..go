@@ -17,13 +17,28 @@ type DynamoDB interface {
 	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
 }
 
-func NewDynamoDBClient(ctx context.Context, secrets *Secrets) (*dynamodb.Client, error) {
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(secrets.Aws.AccessKey, secrets.Aws.SecretKey, "")),
-		config.WithRegion("us-east-1"),
+func NewDynamoDBClient(ctx context.Context, cfg Config, secrets *Secrets) (*dynamodb.Client, error) {
+	cfg = cfg.withDefaults()
+	if secrets == nil {
+		secrets = &Secrets{}
+	}
+	accessKey, secretKey := secrets.Aws.AccessKey, secrets.Aws.SecretKey
+	if cfg.Endpoint != "" && accessKey == "" {
+		// emulators such as DynamoDB Local accept any credentials
+		accessKey, secretKey = "local", "local"
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx,
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKey, secretKey, "")),
+		config.WithRegion(cfg.Region),
 	)
 	if err != nil {
 		return nil, err
 	}
-	return dynamodb.NewFromConfig(cfg), nil
+	var opts []func(*dynamodb.Options)
+	if cfg.Endpoint != "" {
+		opts = append(opts, func(o *dynamodb.Options) {
+			o.EndpointResolver = dynamodb.EndpointResolverFromURL(cfg.Endpoint)
+		})
+	}
+	return dynamodb.NewFromConfig(awsCfg, opts...), nil
 }
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// fileLabelStore is an in-memory label store persisted to a json file after every change. Each
+// change rewrites the whole file, which suits the few thousand labels of a dev node or community
+// runner but not the history of a production bot.
+type fileLabelStore struct {
+	*memoryLabelStore
+	filename string
+	// saveMu orders the writes so that the last one always holds the latest labels
+	saveMu sync.Mutex
+}
+
+// NewFileLabelStore returns a label store persisted to filename, loading the labels already in it
+func NewFileLabelStore(filename string) (LabelStore, error) {
+	s := &fileLabelStore{
+		memoryLabelStore: NewMemoryLabelStore().(*memoryLabelStore),
+		filename:         filename,
+	}
+	b, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored map[string][]*Label
+	if err := json.Unmarshal(b, &stored); err != nil {
+		return nil, err
+	}
+	for entity, labels := range stored {
+		for _, l := range labels {
+			*s.put(cleanTxt(entity), cleanTxt(l.Label)) = *l
+		}
+	}
+	return s, nil
+}
+
+// save writes the labels to a temporary file first so that a crash never leaves a partial file
+func (s *fileLabelStore) save() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	s.mu.RLock()
+	stored := make(map[string][]*Label)
+	for entity, labels := range s.labels {
+		for _, l := range labels {
+			stored[entity] = append(stored[entity], l)
+		}
+		sortHistory(stored[entity])
+	}
+
+	b, err := json.MarshalIndent(stored, "", "  ")
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.filename), filepath.Base(s.filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.filename)
+}
+
+func (s *fileLabelStore) PutLabel(ctx context.Context, entity, label string, src LabelSource) error {
+	if err := s.memoryLabelStore.PutLabel(ctx, entity, label, src); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *fileLabelStore) DeleteLabel(ctx context.Context, entity, label string) error {
+	if err := s.memoryLabelStore.DeleteLabel(ctx, entity, label); err != nil {
+		return err
+	}
+	return s.save()
+}
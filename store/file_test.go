@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileLabelStore(t *testing.T) {
+	testConformance(t, func(t *testing.T) LabelStore {
+		s, err := NewFileLabelStore(filepath.Join(t.TempDir(), "labels.json"))
+		assert.NoError(t, err)
+		return s
+	})
+}
+
+func TestFileLabelStore_Reopen(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "labels.json")
+	s, err := NewFileLabelStore(filename)
+	assert.NoError(t, err)
+	assert.NoError(t, s.PutLabel(ctx, "0xabc", "exploit|heist", LabelSource{}))
+	assert.NoError(t, s.PutLabel(ctx, "0xabc", "name|exploiter", LabelSource{}))
+	assert.NoError(t, s.DeleteLabel(ctx, "0xabc", "name|exploiter"))
+
+	s, err = NewFileLabelStore(filename)
+	assert.NoError(t, err)
+	l, err := s.GetLabel(ctx, "0xabc", "exploit|heist")
+	assert.NoError(t, err)
+	assert.NotNil(t, l)
+	l, err = s.GetLabel(ctx, "0xabc", "name|exploiter")
+	assert.NoError(t, err)
+	assert.Nil(t, l)
+
+	// the history survives too
+	ls, err := s.ListLabels(ctx, "0xabc")
+	assert.NoError(t, err)
+	assert.Len(t, ls, 2)
+
+	assert.NoError(t, os.WriteFile(filename, []byte("not json"), 0o644))
+	_, err = NewFileLabelStore(filename)
+	assert.Error(t, err)
+}
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(ctx, Config{Backend: BackendMemory}, 1, "0xbot", nil)
+	assert.NoError(t, err)
+	assert.IsType(t, &memoryLabelStore{}, s)
+
+	_, err = Open(ctx, Config{Backend: BackendFile}, 1, "0xbot", nil)
+	assert.Error(t, err)
+	_, err = Open(ctx, Config{Backend: "bolt"}, 1, "0xbot", nil)
+	assert.Error(t, err)
+}
+
+func TestConfig(t *testing.T) {
+	cfg := Config{}.withDefaults()
+	assert.Equal(t, "prod-research-bot-data", cfg.Table)
+	assert.Equal(t, "us-east-1", cfg.Region)
+	assert.True(t, Config{}.NeedsSecrets())
+	assert.False(t, Config{Endpoint: "http://localhost:8000"}.NeedsSecrets())
+	assert.False(t, Config{Backend: BackendMemory}.NeedsSecrets())
+
+	cfg = Config{Table: "staging-bot-data", Region: "eu-west-1"}.withDefaults()
+	assert.Equal(t, "staging-bot-data", cfg.Table)
+	assert.Equal(t, "eu-west-1", cfg.Region)
+
+	t.Setenv("LABEL_STORE", BackendFile)
+	t.Setenv("LABEL_STORE_FILE", "labels.json")
+	t.Setenv("LABEL_STORE_TTL", "720h")
+	cfg, err := ConfigFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, Config{Backend: BackendFile, File: "labels.json", TTL: 720 * time.Hour}, cfg)
+	t.Setenv("LABEL_STORE_TTL", "a month")
+	_, err = ConfigFromEnv()
+	assert.Error(t, err)
+}
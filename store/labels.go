@@ -2,9 +2,13 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -12,32 +16,92 @@ import (
 )
 
 type Label struct {
-	ItemId  string `dynamodbav:"itemId"`
-	SortKey string `dynamodbav:"sortKey"`
-	Entity  string `dynamodbav:"entity"`
-	Label   string `dynamodbav:"label"`
+	ItemId  string `dynamodbav:"itemId" json:"-"`
+	SortKey string `dynamodbav:"sortKey" json:"-"`
+	Entity  string `dynamodbav:"entity" json:"entity"`
+	Label   string `dynamodbav:"label" json:"label"`
+	// CreatedAt is when the label was first published, UpdatedAt when it was last published
+	CreatedAt time.Time `dynamodbav:"createdAt,unixtime" json:"createdAt"`
+	UpdatedAt time.Time `dynamodbav:"updatedAt,unixtime" json:"updatedAt"`
+	// RemovedAt is set once the label is retracted, the label being kept as history
+	RemovedAt *time.Time `dynamodbav:"removedAt,unixtime,omitempty" json:"removedAt,omitempty"`
+	// TxHash and SourceURL are the transaction and explorer page the label was first published for
+	TxHash    string `dynamodbav:"txHash,omitempty" json:"txHash,omitempty"`
+	SourceURL string `dynamodbav:"sourceUrl,omitempty" json:"sourceUrl,omitempty"`
+	// ExpiresAt is the DynamoDB TTL attribute in unix seconds, 0 if the label never expires
+	ExpiresAt int64 `dynamodbav:"expiresAt,omitempty" json:"-"`
+}
+
+// LabelSource is where a published label came from
+type LabelSource struct {
+	TxHash    string
+	SourceURL string
+}
+
+// live reports whether the label is neither removed nor expired
+func (l *Label) live(now time.Time) bool {
+	return l.RemovedAt == nil && (l.ExpiresAt == 0 || l.ExpiresAt > now.Unix())
+}
+
+// publish records that the label was published (again) now
+func (l *Label) publish(src LabelSource, now time.Time) {
+	if l.CreatedAt.IsZero() {
+		l.CreatedAt = now
+	}
+	l.UpdatedAt = now
+	l.RemovedAt = nil
+	if l.TxHash == "" {
+		l.TxHash = src.TxHash
+	}
+	if l.SourceURL == "" {
+		l.SourceURL = src.SourceURL
+	}
+}
+
+// EntityLabel identifies one label of an entity
+type EntityLabel struct {
+	Entity string
+	Label  string
 }
 
 type LabelStore interface {
 	EntityExists(ctx context.Context, entity string) (bool, error)
 	GetLabel(ctx context.Context, entity, label string) (*Label, error)
-	PutLabel(ctx context.Context, entity, label string) error
+	// GetLabels looks up many labels at once, returning nil for each label that isn't stored
+	GetLabels(ctx context.Context, keys []EntityLabel) ([]*Label, error)
+	// PutLabel records that the label was published, keeping when and where it first was
+	PutLabel(ctx context.Context, entity, label string, src LabelSource) error
+	ListEntityLabels(ctx context.Context, entity string) ([]*Label, error)
+	// DeleteLabel marks the label removed; it is then only returned by ListLabels
+	DeleteLabel(ctx context.Context, entity, label string) error
+	// ListLabels returns the history of the entity: every label including removed ones,
+	// oldest first
+	ListLabels(ctx context.Context, entity string) ([]*Label, error)
+	// ListEntities returns up to limit stored entities after cursor (any number if limit isn't
+	// positive), and the cursor of the next batch ("" once every entity has been listed)
+	ListEntities(ctx context.Context, cursor string, limit int) ([]string, string, error)
 }
 
-var table = "prod-research-bot-data"
-
 type labelStore struct {
-	chainID int64
-	botID   string
-	db      DynamoDB
+	table string
+	// ttl sets the expiresAt attribute of labels when positive
+	ttl time.Duration
+	// prefix starts the itemId of every label of this bot on this chain
+	prefix string
+	db     DynamoDB
 }
 
-func (s *labelStore) itemId(entity string) string {
-	ID := cleanTxt(fmt.Sprintf("%s|etherscan-labels|%s", s.botID, entity))
-	if s.chainID == 1 {
-		return ID
+// defaultKeyPrefix scopes the items of a bot on a chain, mainnet items having no chain
+func defaultKeyPrefix(chainID int64, botID string) string {
+	prefix := cleanTxt(botID) + "|etherscan-labels|"
+	if chainID == 1 {
+		return prefix
 	}
-	return fmt.Sprintf("%d|%s", s.chainID, ID)
+	return fmt.Sprintf("%d|%s", chainID, prefix)
+}
+
+func (s *labelStore) itemId(entity string) string {
+	return s.prefix + cleanTxt(entity)
 }
 
 func cleanTxt(txt string) string {
@@ -45,31 +109,18 @@ func cleanTxt(txt string) string {
 }
 
 func (s *labelStore) EntityExists(ctx context.Context, entity string) (bool, error) {
-	keyEx := expression.Key("itemId").Equal(expression.Value(s.itemId(entity)))
-	expr, err := expression.NewBuilder().WithKeyCondition(keyEx).Build()
+	ls, err := s.ListEntityLabels(ctx, entity)
 	if err != nil {
 		return false, err
 	}
-	res, err := s.db.Query(ctx, &dynamodb.QueryInput{
-		TableName:                 &table,
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		KeyConditionExpression:    expr.KeyCondition(),
-		Select:                    types.SelectCount,
-	})
-	if err != nil {
-		return false, err
-	}
-	return res.Count > 0, nil
+	return len(ls) > 0, nil
 }
 
-func (s *labelStore) GetLabel(ctx context.Context, entity, label string) (*Label, error) {
+// getItem returns the stored label, removed or expired ones included
+func (s *labelStore) getItem(ctx context.Context, entity, label string) (*Label, error) {
 	res, err := s.db.GetItem(ctx, &dynamodb.GetItemInput{
-		Key: map[string]types.AttributeValue{
-			"itemId":  &types.AttributeValueMemberS{Value: s.itemId(entity)},
-			"sortKey": &types.AttributeValueMemberS{Value: cleanTxt(label)},
-		},
-		TableName: &table,
+		Key:       s.key(entity, label),
+		TableName: &s.table,
 	})
 	if err != nil {
 		return nil, err
@@ -84,39 +135,397 @@ func (s *labelStore) GetLabel(ctx context.Context, entity, label string) (*Label
 	return &result, nil
 }
 
-func (s *labelStore) PutLabel(ctx context.Context, entity, label string) error {
-	item, err := attributevalue.MarshalMap(&Label{
-		ItemId:  s.itemId(entity),
-		SortKey: cleanTxt(label),
-		Entity:  cleanTxt(entity),
-		Label:   cleanTxt(label),
+func (s *labelStore) GetLabel(ctx context.Context, entity, label string) (*Label, error) {
+	l, err := s.getItem(ctx, entity, label)
+	if err != nil || l == nil || !l.live(time.Now()) {
+		return nil, err
+	}
+	return l, nil
+}
+
+// batchGetLimit is the most keys DynamoDB accepts in one BatchGetItem call
+const batchGetLimit = 100
+
+// batchGetRetries bounds the attempts at keys DynamoDB leaves unprocessed under load
+const batchGetRetries = 5
+
+var batchGetBackoff = 50 * time.Millisecond
+
+func (s *labelStore) key(entity, label string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"itemId":  &types.AttributeValueMemberS{Value: s.itemId(entity)},
+		"sortKey": &types.AttributeValueMemberS{Value: cleanTxt(label)},
+	}
+}
+
+func (s *labelStore) GetLabels(ctx context.Context, keys []EntityLabel) ([]*Label, error) {
+	// BatchGetItem rejects duplicate keys
+	type itemKey struct{ itemId, sortKey string }
+	found := make(map[itemKey]*Label)
+	var unique []map[string]types.AttributeValue
+	for _, k := range keys {
+		ik := itemKey{s.itemId(k.Entity), cleanTxt(k.Label)}
+		if _, ok := found[ik]; ok {
+			continue
+		}
+		found[ik] = nil
+		unique = append(unique, s.key(k.Entity, k.Label))
+	}
+
+	for start := 0; start < len(unique); start += batchGetLimit {
+		end := start + batchGetLimit
+		if end > len(unique) {
+			end = len(unique)
+		}
+		pending := map[string]types.KeysAndAttributes{
+			s.table: {Keys: unique[start:end]},
+		}
+		for attempt := 0; len(pending) > 0; attempt++ {
+			if attempt > 0 {
+				if attempt >= batchGetRetries {
+					return nil, fmt.Errorf("%d keys still unprocessed after %d attempts", len(pending[s.table].Keys), attempt)
+				}
+				select {
+				case <-time.After(batchGetBackoff << (attempt - 1)):
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			}
+			res, err := s.db.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: pending})
+			if err != nil {
+				return nil, err
+			}
+			var page []*Label
+			if err := attributevalue.UnmarshalListOfMaps(res.Responses[s.table], &page); err != nil {
+				return nil, err
+			}
+			now := time.Now()
+			for _, l := range page {
+				if l.live(now) {
+					found[itemKey{l.ItemId, l.SortKey}] = l
+				}
+			}
+			pending = res.UnprocessedKeys
+		}
+	}
+
+	result := make([]*Label, len(keys))
+	for i, k := range keys {
+		result[i] = found[itemKey{s.itemId(k.Entity), cleanTxt(k.Label)}]
+	}
+	return result, nil
+}
+
+// PutLabel publishes the label in a single UpdateItem: the first publish's time, transaction
+// and page are kept with if_not_exists, so concurrent publishes can't overwrite each other
+func (s *labelStore) PutLabel(ctx context.Context, entity, label string, src LabelSource) error {
+	now := time.Now()
+	old, err := s.publishItem(ctx, entity, label, src, now, true)
+	if err == nil && old != nil && old.ExpiresAt != 0 && old.ExpiresAt <= now.Unix() {
+		// an expired label that DynamoDB hasn't deleted yet starts over
+		_, err = s.publishItem(ctx, entity, label, src, now, false)
+	}
+	if err != nil || (old != nil && old.live(now)) {
+		return err
+	}
+	return s.indexEntity(ctx, entity, now)
+}
+
+// publishItem updates the stored label and returns it as it was before, nil if it was new.
+// Without keepFirst the first publish is overwritten instead of kept.
+func (s *labelStore) publishItem(ctx context.Context, entity, label string, src LabelSource, now time.Time, keepFirst bool) (*Label, error) {
+	upd := expression.Set(expression.Name("entity"), expression.Value(cleanTxt(entity))).
+		Set(expression.Name("label"), expression.Value(cleanTxt(label))).
+		Set(expression.Name("updatedAt"), expression.Value(now.Unix())).
+		Remove(expression.Name("removedAt"))
+	first := func(name string, v interface{}) {
+		if keepFirst {
+			upd = upd.Set(expression.Name(name), expression.Name(name).IfNotExists(expression.Value(v)))
+		} else {
+			upd = upd.Set(expression.Name(name), expression.Value(v))
+		}
+	}
+	first("createdAt", now.Unix())
+	for name, v := range map[string]string{"txHash": src.TxHash, "sourceUrl": src.SourceURL} {
+		if v != "" {
+			first(name, v)
+		} else if !keepFirst {
+			upd = upd.Remove(expression.Name(name))
+		}
+	}
+	if s.ttl > 0 {
+		upd = upd.Set(expression.Name("expiresAt"), expression.Value(now.Add(s.ttl).Unix()))
+	}
+	expr, err := expression.NewBuilder().WithUpdate(upd).Build()
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 &s.table,
+		Key:                       s.key(entity, label),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ReturnValues:              types.ReturnValueAllOld,
+	})
+	if err != nil || len(res.Attributes) == 0 {
+		return nil, err
+	}
+	var old Label
+	if err := attributevalue.UnmarshalMap(res.Attributes, &old); err != nil {
+		return nil, err
+	}
+	return &old, nil
+}
+
+func (s *labelStore) ListEntityLabels(ctx context.Context, entity string) ([]*Label, error) {
+	ls, err := s.queryEntity(ctx, entity)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var result []*Label
+	for _, l := range ls {
+		if l.live(now) {
+			result = append(result, l)
+		}
+	}
+	return result, nil
+}
+
+// queryEntity returns every stored label of the entity, removed or expired ones included
+func (s *labelStore) queryEntity(ctx context.Context, entity string) ([]*Label, error) {
+	keyEx := expression.Key("itemId").Equal(expression.Value(s.itemId(entity)))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyEx).Build()
+	if err != nil {
+		return nil, err
+	}
+	var result []*Label
+	var startKey map[string]types.AttributeValue
+	for {
+		res, err := s.db.Query(ctx, &dynamodb.QueryInput{
+			TableName:                 &s.table,
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			KeyConditionExpression:    expr.KeyCondition(),
+			ExclusiveStartKey:         startKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var page []*Label
+		if err := attributevalue.UnmarshalListOfMaps(res.Items, &page); err != nil {
+			return nil, err
+		}
+		result = append(result, page...)
+		if len(res.LastEvaluatedKey) == 0 {
+			return result, nil
+		}
+		startKey = res.LastEvaluatedKey
+	}
+}
+
+// DeleteLabel marks the label removed in a single UpdateItem, keeping the first removal time
+func (s *labelStore) DeleteLabel(ctx context.Context, entity, label string) error {
+	now := time.Now()
+	upd := expression.Set(expression.Name("removedAt"), expression.Name("removedAt").IfNotExists(expression.Value(now.Unix())))
+	if s.ttl > 0 {
+		upd = upd.Set(expression.Name("expiresAt"), expression.Value(now.Add(s.ttl).Unix()))
+	}
+	// missing labels are not created
+	cond := expression.AttributeExists(expression.Name("itemId"))
+	expr, err := expression.NewBuilder().WithUpdate(upd).WithCondition(cond).Build()
+	if err != nil {
+		return err
+	}
+	_, err = s.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 &s.table,
+		Key:                       s.key(entity, label),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
 	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
+	live, err := s.ListEntityLabels(ctx, entity)
+	if err != nil || len(live) > 0 {
+		return err
+	}
+	_, err = s.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: &s.table,
+		Key: map[string]types.AttributeValue{
+			"itemId":  &types.AttributeValueMemberS{Value: s.indexId()},
+			"sortKey": &types.AttributeValueMemberS{Value: cleanTxt(entity)},
+		},
+	})
+	return err
+}
+
+func (s *labelStore) ListLabels(ctx context.Context, entity string) ([]*Label, error) {
+	ls, err := s.queryEntity(ctx, entity)
+	if err != nil {
+		return nil, err
+	}
+	sortHistory(ls)
+	return ls, nil
+}
+
+// sortHistory orders labels by when they were first published
+func sortHistory(ls []*Label) {
+	sort.SliceStable(ls, func(i, j int) bool {
+		if !ls[i].CreatedAt.Equal(ls[j].CreatedAt) {
+			return ls[i].CreatedAt.Before(ls[j].CreatedAt)
+		}
+		return ls[i].Label < ls[j].Label
+	})
+}
 
+// entityIndexKey names the partition that lists the entities with labels, one item per entity,
+// so that they can be paged through with a Query instead of scanning the shared table
+const entityIndexKey = "#entities"
+
+func (s *labelStore) indexId() string {
+	return s.prefix + entityIndexKey
+}
+
+// indexEntity adds the entity to the index; with a ttl the entry expires with the label
+// that added it
+func (s *labelStore) indexEntity(ctx context.Context, entity string, now time.Time) error {
+	l := &Label{ItemId: s.indexId(), SortKey: cleanTxt(entity), Entity: cleanTxt(entity)}
+	if s.ttl > 0 {
+		l.ExpiresAt = now.Add(s.ttl).Unix()
+	}
+	item, err := attributevalue.MarshalMap(l)
+	if err != nil {
+		return err
+	}
 	_, err = s.db.PutItem(ctx, &dynamodb.PutItemInput{
 		Item:      item,
-		TableName: &table,
+		TableName: &s.table,
 	})
-
 	return err
 }
 
+// ListEntities pages through the entity index, the cursor being the last entity returned
+func (s *labelStore) ListEntities(ctx context.Context, cursor string, limit int) ([]string, string, error) {
+	keyEx := expression.Key("itemId").Equal(expression.Value(s.indexId()))
+	if cursor != "" {
+		keyEx = keyEx.And(expression.Key("sortKey").GreaterThan(expression.Value(cursor)))
+	}
+	expr, err := expression.NewBuilder().WithKeyCondition(keyEx).Build()
+	if err != nil {
+		return nil, "", err
+	}
+	input := &dynamodb.QueryInput{
+		TableName:                 &s.table,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	}
+	if limit > 0 {
+		input.Limit = aws.Int32(int32(limit))
+	}
+	res, err := s.db.Query(ctx, input)
+	if err != nil {
+		return nil, "", err
+	}
+	var page []*Label
+	if err := attributevalue.UnmarshalListOfMaps(res.Items, &page); err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	var result []string
+	for _, l := range page {
+		if l.live(now) {
+			result = append(result, l.Entity)
+		}
+	}
+	if len(res.LastEvaluatedKey) == 0 || len(page) == 0 {
+		return result, "", nil
+	}
+	return result, page[len(page)-1].Entity, nil
+}
+
+// EntityIndexer is implemented by stores that list entities through an index, which the labels
+// written before the index existed are missing from
+type EntityIndexer interface {
+	// IndexEntities adds every entity with labels to the index and returns how many there are
+	IndexEntities(ctx context.Context) (int, error)
+}
+
+// IndexEntities scans the whole table once, so it is meant to be run by operators
+func (s *labelStore) IndexEntities(ctx context.Context) (int, error) {
+	// the table is shared, so only this bot's items on this chain are kept
+	filt := expression.Name("itemId").BeginsWith(s.prefix)
+	expr, err := expression.NewBuilder().WithFilter(filt).Build()
+	if err != nil {
+		return 0, err
+	}
+	indexed := make(map[string]bool)
+	var startKey map[string]types.AttributeValue
+	for {
+		res, err := s.db.Scan(ctx, &dynamodb.ScanInput{
+			TableName:                 &s.table,
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			FilterExpression:          expr.Filter(),
+			ExclusiveStartKey:         startKey,
+		})
+		if err != nil {
+			return len(indexed), err
+		}
+		var page []*Label
+		if err := attributevalue.UnmarshalListOfMaps(res.Items, &page); err != nil {
+			return len(indexed), err
+		}
+		now := time.Now()
+		for _, l := range page {
+			if l.ItemId == s.indexId() || !l.live(now) || indexed[l.Entity] {
+				continue
+			}
+			if err := s.indexEntity(ctx, l.Entity, now); err != nil {
+				return len(indexed), err
+			}
+			indexed[l.Entity] = true
+		}
+		if len(res.LastEvaluatedKey) == 0 {
+			return len(indexed), nil
+		}
+		startKey = res.LastEvaluatedKey
+	}
+}
+
+// NewLabelStore returns the DynamoDB label store of the default table
 func NewLabelStore(ctx context.Context, chainID int64, botID string, secrets *Secrets) (LabelStore, error) {
+	return newDynamoLabelStore(ctx, Config{}, chainID, botID, secrets)
+}
+
+func newDynamoLabelStore(ctx context.Context, cfg Config, chainID int64, botID string, secrets *Secrets) (LabelStore, error) {
 	if botID == "" {
 		panic("botID is nil")
 	}
 	if chainID == 0 {
 		panic("chainID is 0")
 	}
-	db, err := NewDynamoDBClient(ctx, secrets)
+	cfg = cfg.withDefaults()
+	db, err := NewDynamoDBClient(ctx, cfg, secrets)
 	if err != nil {
 		return nil, err
 	}
+	prefix := cfg.KeyPrefix
+	if prefix == "" {
+		prefix = defaultKeyPrefix(chainID, botID)
+	}
 	return &labelStore{
-		chainID: chainID,
-		botID:   botID,
-		db:      db,
+		ttl:    cfg.TTL,
+		table:  cfg.Table,
+		prefix: prefix,
+		db:     db,
 	}, nil
 }
@@ -0,0 +1,416 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDynamoDB keeps items by itemId and sortKey, understanding just the expressions labelStore builds
+type fakeDynamoDB struct {
+	DynamoDB
+	mu    sync.Mutex
+	items map[[2]string]map[string]types.AttributeValue
+	// unprocessed is how many BatchGetItem calls leave half of their keys unprocessed
+	unprocessed int
+	batchCalls  int
+	getCalls    int
+	updateCalls int
+	scanCalls   int
+}
+
+func newFakeDynamoDB() *fakeDynamoDB {
+	return &fakeDynamoDB{items: make(map[[2]string]map[string]types.AttributeValue)}
+}
+
+func attrS(item map[string]types.AttributeValue, name string) string {
+	if v, ok := item[name].(*types.AttributeValueMemberS); ok {
+		return v.Value
+	}
+	return ""
+}
+
+func itemKeyOf(item map[string]types.AttributeValue) [2]string {
+	return [2]string{attrS(item, "itemId"), attrS(item, "sortKey")}
+}
+
+// exprValue returns the single value of an expression built by labelStore
+func exprValue(values map[string]types.AttributeValue) string {
+	for _, v := range values {
+		return v.(*types.AttributeValueMemberS).Value
+	}
+	return ""
+}
+
+func (f *fakeDynamoDB) sortedKeys() [][2]string {
+	var keys [][2]string
+	for k := range f.items {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys
+}
+
+func (f *fakeDynamoDB) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.getCalls++
+	return &dynamodb.GetItemOutput{Item: f.items[itemKeyOf(params.Key)]}, nil
+}
+
+func (f *fakeDynamoDB) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.items[itemKeyOf(params.Item)] = params.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.items, itemKeyOf(params.Key))
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+var (
+	setClause    = regexp.MustCompile(`(#\d+) = (?:if_not_exists\((#\d+), (:\d+)\)|(:\d+))`)
+	removeClause = regexp.MustCompile(`REMOVE (.*)`)
+	keyClause    = regexp.MustCompile(`(#\d+) (=|>) (:\d+)`)
+)
+
+// UpdateItem understands SET with plain values and if_not_exists, REMOVE, and an
+// attribute_exists condition
+func (f *fakeDynamoDB) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updateCalls++
+	k := itemKeyOf(params.Key)
+	old, exists := f.items[k]
+	if params.ConditionExpression != nil && strings.Contains(*params.ConditionExpression, "attribute_exists") && !exists {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	item := make(map[string]types.AttributeValue)
+	for name, v := range old {
+		item[name] = v
+	}
+	for name, v := range params.Key {
+		item[name] = v
+	}
+	for _, m := range setClause.FindAllStringSubmatch(*params.UpdateExpression, -1) {
+		name := params.ExpressionAttributeNames[m[1]]
+		if m[2] != "" {
+			if _, ok := item[name]; !ok {
+				item[name] = params.ExpressionAttributeValues[m[3]]
+			}
+			continue
+		}
+		item[name] = params.ExpressionAttributeValues[m[4]]
+	}
+	if m := removeClause.FindStringSubmatch(*params.UpdateExpression); m != nil {
+		for _, ref := range strings.Split(m[1], ", ") {
+			delete(item, params.ExpressionAttributeNames[strings.TrimSpace(ref)])
+		}
+	}
+	f.items[k] = item
+	res := &dynamodb.UpdateItemOutput{}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		res.Attributes = old
+	}
+	return res, nil
+}
+
+func (f *fakeDynamoDB) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var itemId, after string
+	for _, m := range keyClause.FindAllStringSubmatch(*params.KeyConditionExpression, -1) {
+		v := params.ExpressionAttributeValues[m[3]].(*types.AttributeValueMemberS).Value
+		if params.ExpressionAttributeNames[m[1]] == "itemId" {
+			itemId = v
+		} else {
+			after = v
+		}
+	}
+	res := &dynamodb.QueryOutput{}
+	for _, k := range f.sortedKeys() {
+		if k[0] != itemId || (after != "" && k[1] <= after) {
+			continue
+		}
+		res.Items = append(res.Items, f.items[k])
+		if params.Limit != nil && len(res.Items) == int(*params.Limit) {
+			res.LastEvaluatedKey = map[string]types.AttributeValue{
+				"itemId":  &types.AttributeValueMemberS{Value: k[0]},
+				"sortKey": &types.AttributeValueMemberS{Value: k[1]},
+			}
+			break
+		}
+	}
+	res.Count = int32(len(res.Items))
+	if params.Select == types.SelectCount {
+		res.Items = nil
+	}
+	return res, nil
+}
+
+func (f *fakeDynamoDB) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scanCalls++
+	prefix := exprValue(params.ExpressionAttributeValues)
+	keys := f.sortedKeys()
+	start := 0
+	if params.ExclusiveStartKey != nil {
+		after := itemKeyOf(params.ExclusiveStartKey)
+		for start < len(keys) && (keys[start][0] < after[0] || (keys[start][0] == after[0] && keys[start][1] <= after[1])) {
+			start++
+		}
+	}
+	res := &dynamodb.ScanOutput{}
+	end := len(keys)
+	if params.Limit != nil && start+int(*params.Limit) < end {
+		end = start + int(*params.Limit)
+		res.LastEvaluatedKey = map[string]types.AttributeValue{
+			"itemId":  &types.AttributeValueMemberS{Value: keys[end-1][0]},
+			"sortKey": &types.AttributeValueMemberS{Value: keys[end-1][1]},
+		}
+	}
+	for _, k := range keys[start:end] {
+		if strings.HasPrefix(k[0], prefix) {
+			res.Items = append(res.Items, f.items[k])
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeDynamoDB) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batchCalls++
+	res := &dynamodb.BatchGetItemOutput{
+		Responses:       make(map[string][]map[string]types.AttributeValue),
+		UnprocessedKeys: make(map[string]types.KeysAndAttributes),
+	}
+	for tableName, ka := range params.RequestItems {
+		if len(ka.Keys) > batchGetLimit {
+			return nil, fmt.Errorf("too many keys: %d", len(ka.Keys))
+		}
+		keys := ka.Keys
+		if f.unprocessed > 0 && len(keys) > 1 {
+			f.unprocessed--
+			res.UnprocessedKeys[tableName] = types.KeysAndAttributes{Keys: keys[len(keys)/2:]}
+			keys = keys[:len(keys)/2]
+		}
+		for _, k := range keys {
+			if item, ok := f.items[itemKeyOf(k)]; ok {
+				res.Responses[tableName] = append(res.Responses[tableName], item)
+			}
+		}
+	}
+	return res, nil
+}
+
+func TestLabelStore(t *testing.T) {
+	testConformance(t, func(t *testing.T) LabelStore {
+		return &labelStore{table: defaultTable, prefix: defaultKeyPrefix(1, "0xBot"), db: newFakeDynamoDB()}
+	})
+}
+
+func TestLabelStore_GetLabels(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeDynamoDB()
+	s := &labelStore{table: "staging-bot-data", prefix: defaultKeyPrefix(56, "0xbot"), db: db}
+
+	var keys []EntityLabel
+	for i := 0; i < 250; i++ {
+		entity := "0x" + strings.Repeat("a", i%40+1)
+		label := "label" + strings.Repeat("x", i/40)
+		keys = append(keys, EntityLabel{Entity: entity, Label: label})
+		if i%2 == 0 {
+			assert.NoError(t, s.PutLabel(ctx, entity, label, LabelSource{}))
+		}
+	}
+	db.unprocessed = 2
+	ls, err := s.GetLabels(ctx, keys)
+	assert.NoError(t, err)
+	assert.Len(t, ls, 250)
+	for i, l := range ls {
+		if i%2 == 0 {
+			assert.NotNil(t, l, i)
+			assert.Equal(t, keys[i].Label, l.Label)
+		} else {
+			assert.Nil(t, l, i)
+		}
+	}
+	// three chunks, two of which needed a retry
+	assert.Equal(t, 5, db.batchCalls)
+
+	// a table that never catches up fails instead of retrying forever
+	defer func(d time.Duration) { batchGetBackoff = d }(batchGetBackoff)
+	batchGetBackoff = 0
+	db.unprocessed = 100
+	_, err = s.GetLabels(ctx, keys[:100])
+	assert.Error(t, err)
+}
+
+func TestLabelStore_KeyPrefix(t *testing.T) {
+	assert.Equal(t, "0xbot|etherscan-labels|", defaultKeyPrefix(1, "0xBot"))
+	assert.Equal(t, "56|0xbot|etherscan-labels|", defaultKeyPrefix(56, "0xBot"))
+
+	ctx := context.Background()
+	db := newFakeDynamoDB()
+	s := &labelStore{table: "staging-bot-data", prefix: "staging|", db: db}
+	assert.NoError(t, s.PutLabel(ctx, "0xABC", "exploit|heist", LabelSource{}))
+	_, ok := db.items[[2]string{"staging|0xabc", "exploit|heist"}]
+	assert.True(t, ok)
+
+	// other prefixes sharing the table are left out of listings
+	other := &labelStore{table: "staging-bot-data", prefix: "prod|", db: db}
+	assert.NoError(t, other.PutLabel(ctx, "0xdef", "exploit|heist", LabelSource{}))
+	entities, _, err := s.ListEntities(ctx, "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xabc"}, entities)
+}
+
+func TestLabelStore_TTL(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeDynamoDB()
+	s := &labelStore{table: "table", prefix: "0xbot|etherscan-labels|", db: db, ttl: time.Hour}
+	assert.NoError(t, s.PutLabel(ctx, "0xabc", "exploit|heist", LabelSource{TxHash: "0xtx"}))
+	item := db.items[[2]string{"0xbot|etherscan-labels|0xabc", "exploit|heist"}]
+	var stored Label
+	assert.NoError(t, attributevalue.UnmarshalMap(item, &stored))
+	assert.InDelta(t, time.Now().Add(time.Hour).Unix(), stored.ExpiresAt, 2)
+
+	// the index entry expires with the label
+	indexKey := [2]string{"0xbot|etherscan-labels|#entities", "0xabc"}
+	var index Label
+	assert.NoError(t, attributevalue.UnmarshalMap(db.items[indexKey], &index))
+	assert.Equal(t, stored.ExpiresAt, index.ExpiresAt)
+
+	// expired items DynamoDB hasn't deleted yet are ignored, and published afresh
+	stored.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	item, err := attributevalue.MarshalMap(&stored)
+	assert.NoError(t, err)
+	db.items[[2]string{"0xbot|etherscan-labels|0xabc", "exploit|heist"}] = item
+	index.ExpiresAt = stored.ExpiresAt
+	item, err = attributevalue.MarshalMap(&index)
+	assert.NoError(t, err)
+	db.items[indexKey] = item
+	l, err := s.GetLabel(ctx, "0xabc", "exploit|heist")
+	assert.NoError(t, err)
+	assert.Nil(t, l)
+	exists, err := s.EntityExists(ctx, "0xabc")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	entities, _, err := s.ListEntities(ctx, "", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, entities)
+
+	assert.NoError(t, s.PutLabel(ctx, "0xabc", "exploit|heist", LabelSource{TxHash: "0xtx2"}))
+	l, err = s.GetLabel(ctx, "0xabc", "exploit|heist")
+	assert.NoError(t, err)
+	if assert.NotNil(t, l) {
+		assert.Equal(t, "0xtx2", l.TxHash)
+	}
+
+	// without a ttl labels never expire
+	s.ttl = 0
+	assert.NoError(t, s.PutLabel(ctx, "0xdef", "exploit|heist", LabelSource{}))
+	l, err = s.GetLabel(ctx, "0xdef", "exploit|heist")
+	assert.NoError(t, err)
+	assert.Zero(t, l.ExpiresAt)
+}
+
+func TestLabelStore_PutLabelRoundTrips(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeDynamoDB()
+	s := &labelStore{table: "table", prefix: "0xbot|etherscan-labels|", db: db}
+	assert.NoError(t, s.PutLabel(ctx, "0xabc", "exploit|heist", LabelSource{TxHash: "0xtx1"}))
+	assert.NoError(t, s.PutLabel(ctx, "0xabc", "exploit|heist", LabelSource{TxHash: "0xtx2"}))
+	assert.NoError(t, s.DeleteLabel(ctx, "0xabc", "exploit|heist"))
+	// publishing and removing never read the item first
+	assert.Equal(t, 0, db.getCalls)
+	assert.Equal(t, 3, db.updateCalls)
+
+	// removing a missing label doesn't create it
+	assert.NoError(t, s.DeleteLabel(ctx, "0xabc", "name|exploiter"))
+	ls, err := s.ListLabels(ctx, "0xabc")
+	assert.NoError(t, err)
+	assert.Len(t, ls, 1)
+}
+
+func TestLabelStore_EntityIndex(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeDynamoDB()
+	s := &labelStore{table: "table", prefix: "0xbot|etherscan-labels|", db: db}
+	assert.NoError(t, s.PutLabel(ctx, "0xabc", "exploit|heist", LabelSource{}))
+	assert.NoError(t, s.PutLabel(ctx, "0xabc", "name|exploiter", LabelSource{}))
+	assert.NoError(t, s.PutLabel(ctx, "0xdef", "scam|phish / hack", LabelSource{}))
+	_, ok := db.items[[2]string{"0xbot|etherscan-labels|#entities", "0xabc"}]
+	assert.True(t, ok)
+
+	// entities are listed from the index without scanning the table
+	entities, next, err := s.ListEntities(ctx, "", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xabc"}, entities)
+	entities, _, err = s.ListEntities(ctx, next, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xdef"}, entities)
+	assert.Equal(t, 0, db.scanCalls)
+
+	// the entry goes once the entity has no labels left
+	assert.NoError(t, s.DeleteLabel(ctx, "0xabc", "exploit|heist"))
+	_, ok = db.items[[2]string{"0xbot|etherscan-labels|#entities", "0xabc"}]
+	assert.True(t, ok)
+	assert.NoError(t, s.DeleteLabel(ctx, "0xabc", "name|exploiter"))
+	_, ok = db.items[[2]string{"0xbot|etherscan-labels|#entities", "0xabc"}]
+	assert.False(t, ok)
+
+	// restoring a removed label indexes the entity again
+	assert.NoError(t, s.PutLabel(ctx, "0xabc", "exploit|heist", LabelSource{}))
+	entities, _, err = s.ListEntities(ctx, "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xabc", "0xdef"}, entities)
+}
+
+func TestLabelStore_IndexEntities(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeDynamoDB()
+	s := &labelStore{table: "table", prefix: "0xbot|etherscan-labels|", db: db}
+	// labels written before the index existed
+	for _, l := range []*Label{
+		{ItemId: "0xbot|etherscan-labels|0xabc", SortKey: "exploit|heist", Entity: "0xabc", Label: "exploit|heist"},
+		{ItemId: "0xbot|etherscan-labels|0xabc", SortKey: "name|exploiter", Entity: "0xabc", Label: "name|exploiter"},
+		{ItemId: "0xbot|etherscan-labels|0xdef", SortKey: "scam|phish / hack", Entity: "0xdef", Label: "scam|phish / hack"},
+		{ItemId: "other|0x123", SortKey: "exploit|heist", Entity: "0x123", Label: "exploit|heist"},
+	} {
+		item, err := attributevalue.MarshalMap(l)
+		assert.NoError(t, err)
+		db.items[itemKeyOf(item)] = item
+	}
+	entities, _, err := s.ListEntities(ctx, "", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, entities)
+
+	n, err := s.IndexEntities(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	entities, _, err = s.ListEntities(ctx, "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xabc", "0xdef"}, entities)
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memoryLabelStore keeps labels in memory, for tests and nodes that don't need them to survive restarts
+type memoryLabelStore struct {
+	mu sync.RWMutex
+	// labels holds every label of an entity, removed ones included as history
+	labels map[string]map[string]*Label
+}
+
+// NewMemoryLabelStore returns an empty in-memory label store
+func NewMemoryLabelStore() LabelStore {
+	return &memoryLabelStore{labels: make(map[string]map[string]*Label)}
+}
+
+func (s *memoryLabelStore) EntityExists(ctx context.Context, entity string) (bool, error) {
+	ls, _ := s.ListEntityLabels(ctx, entity)
+	return len(ls) > 0, nil
+}
+
+func (s *memoryLabelStore) GetLabel(ctx context.Context, entity, label string) (*Label, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	l := s.labels[cleanTxt(entity)][cleanTxt(label)]
+	if l == nil || !l.live(time.Now()) {
+		return nil, nil
+	}
+	result := *l
+	return &result, nil
+}
+
+func (s *memoryLabelStore) GetLabels(ctx context.Context, keys []EntityLabel) ([]*Label, error) {
+	result := make([]*Label, len(keys))
+	for i, k := range keys {
+		result[i], _ = s.GetLabel(ctx, k.Entity, k.Label)
+	}
+	return result, nil
+}
+
+func (s *memoryLabelStore) PutLabel(ctx context.Context, entity, label string, src LabelSource) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(cleanTxt(entity), cleanTxt(label)).publish(src, time.Now())
+	return nil
+}
+
+// put returns the stored label, adding it first if needed; s.mu must be held
+func (s *memoryLabelStore) put(entity, label string) *Label {
+	if s.labels[entity] == nil {
+		s.labels[entity] = make(map[string]*Label)
+	}
+	l := s.labels[entity][label]
+	if l == nil {
+		l = &Label{Entity: entity, Label: label}
+		s.labels[entity][label] = l
+	}
+	return l
+}
+
+func (s *memoryLabelStore) ListEntityLabels(ctx context.Context, entity string) ([]*Label, error) {
+	ls, _ := s.ListLabels(ctx, entity)
+	now := time.Now()
+	var result []*Label
+	for _, l := range ls {
+		if l.live(now) {
+			result = append(result, l)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Label < result[j].Label })
+	return result, nil
+}
+
+func (s *memoryLabelStore) ListLabels(ctx context.Context, entity string) ([]*Label, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []*Label
+	for _, l := range s.labels[cleanTxt(entity)] {
+		c := *l
+		result = append(result, &c)
+	}
+	sortHistory(result)
+	return result, nil
+}
+
+func (s *memoryLabelStore) DeleteLabel(ctx context.Context, entity, label string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.labels[cleanTxt(entity)][cleanTxt(label)]
+	if l != nil && l.RemovedAt == nil {
+		now := time.Now()
+		l.RemovedAt = &now
+	}
+	return nil
+}
+
+// ListEntities pages through the entities with live labels in order, the cursor being the
+// last entity returned
+func (s *memoryLabelStore) ListEntities(ctx context.Context, cursor string, limit int) ([]string, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := time.Now()
+	var entities []string
+	for e, labels := range s.labels {
+		if e <= cursor {
+			continue
+		}
+		for _, l := range labels {
+			if l.live(now) {
+				entities = append(entities, e)
+				break
+			}
+		}
+	}
+	sort.Strings(entities)
+	if limit <= 0 || len(entities) <= limit {
+		return entities, "", nil
+	}
+	entities = entities[:limit]
+	return entities, entities[limit-1], nil
+}
@@ -0,0 +1,11 @@
+package store
+
+import (
+	"testing"
+)
+
+func TestMemoryLabelStore(t *testing.T) {
+	testConformance(t, func(t *testing.T) LabelStore {
+		return NewMemoryLabelStore()
+	})
+}
@@ -0,0 +1,24 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	"forta-network/go-agent/botdb"
+)
+
+// LoadParserRules returns the scanner rule file published for the chain, or nil if there is none
+func LoadParserRules(chainID int64) ([]byte, error) {
+	db, err := botdb.NewDefaultClient("https://research.forta.network")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := db.Get(botdb.ScopeOwner, fmt.Sprintf("parser-rules-%d.json", chainID))
+	if errors.Is(err, botdb.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,29 @@
+package store
+
+import (
+	"context"
+)
+
+// PurgeLabels removes the given labels of the entity, or all of its labels when none are given,
+// and returns the labels it removed. Removed labels stay in the entity's history.
+func PurgeLabels(ctx context.Context, s LabelStore, entity string, labels ...string) ([]*Label, error) {
+	existing, err := s.ListEntityLabels(ctx, entity)
+	if err != nil {
+		return nil, err
+	}
+	purge := make(map[string]bool)
+	for _, l := range labels {
+		purge[cleanTxt(l)] = true
+	}
+	var result []*Label
+	for _, l := range existing {
+		if len(purge) > 0 && !purge[l.Label] {
+			continue
+		}
+		if err := s.DeleteLabel(ctx, entity, l.Label); err != nil {
+			return result, err
+		}
+		result = append(result, l)
+	}
+	return result, nil
+}
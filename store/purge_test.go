@@ -0,0 +1,41 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeLabels(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryLabelStore()
+	for _, l := range []string{"exploit|heist", "name|exploiter", "scam|phish / hack"} {
+		assert.NoError(t, s.PutLabel(ctx, "0xabc", l, LabelSource{}))
+	}
+	assert.NoError(t, s.PutLabel(ctx, "0xdef", "exploit|heist", LabelSource{}))
+
+	purged, err := PurgeLabels(ctx, s, "0xABC", "Exploit|Heist", "other|missing")
+	assert.NoError(t, err)
+	if assert.Len(t, purged, 1) {
+		assert.Equal(t, "exploit|heist", purged[0].Label)
+	}
+	ls, err := s.ListEntityLabels(ctx, "0xabc")
+	assert.NoError(t, err)
+	assert.Len(t, ls, 2)
+
+	purged, err = PurgeLabels(ctx, s, "0xabc")
+	assert.NoError(t, err)
+	assert.Len(t, purged, 2)
+	exists, err := s.EntityExists(ctx, "0xabc")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	history, err := s.ListLabels(ctx, "0xabc")
+	assert.NoError(t, err)
+	assert.Len(t, history, 3)
+
+	// other entities are left alone
+	exists, err = s.EntityExists(ctx, "0xdef")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
@@ -19,6 +19,8 @@ type Secrets struct {
 		Polygon   string `json:"polygon"`
 		Avalanche string `json:"avalanche"`
 	} `json:"jsonRpc"`
+	// ExplorerAPIKeys are Etherscan-family api keys by chain id, e.g. {"1": "..."}
+	ExplorerAPIKeys map[string]string `json:"explorerApiKeys"`
 }
 
 func LoadSecretsFromFile(filename string) (*Secrets, error) {
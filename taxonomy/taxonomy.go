@@ -0,0 +1,94 @@
+package taxonomy
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Category is the canonical class of an explorer tag
+type Category string
+
+const (
+	Scam       Category = "scam"
+	Exploit    Category = "exploit"
+	Sanctioned Category = "sanctioned"
+	Mixer      Category = "mixer"
+	Bridge     Category = "bridge"
+	Exchange   Category = "exchange"
+	Stablecoin Category = "stablecoin"
+	Token      Category = "token"
+	Other      Category = "other"
+)
+
+type categoryRule struct {
+	category Category
+	re       *regexp.Regexp
+}
+
+// rules are tried in order against the normalized tag, so "phish / hack" is a scam rather
+// than an exploit. Explorers word their tags differently, so each category covers them all.
+var rules = []categoryRule{
+	{Sanctioned, regexp.MustCompile(`\b(ofac|sanction(ed|s)?|sdn)\b`)},
+	{Scam, regexp.MustCompile(`\b(phish(ing)?|scam(mer)?|fake|fraud|ponzi|rug ?pull|honeypot|impersonat\w*|drainer)\b`)},
+	{Exploit, regexp.MustCompile(`\b(heist|exploit(er)?|hack(er)?|attacker|compromised)\b`)},
+	{Mixer, regexp.MustCompile(`\b(tornado( cash)?|mixer)\b`)},
+	{Bridge, regexp.MustCompile(`\bbridge\b`)},
+	{Exchange, regexp.MustCompile(`\b(exchange|dex|cex|hot wallet|cold wallet|deposit)\b`)},
+	{Stablecoin, regexp.MustCompile(`\bstablecoin\b`)},
+	{Token, regexp.MustCompile(`\b(token contract|erc(20|721|1155)|nft)\b`)},
+}
+
+var (
+	// token standards are spelled "ERC-20", "ERC 20" or "ERC20"
+	tokenStandards = regexp.MustCompile(`\berc[\s_\-]?(20|721|1155)\b`)
+	separators     = regexp.MustCompile(`[_\-:/|()]+`)
+	// numbers attached to a word, as in "phishing5814"
+	numSuffixes = regexp.MustCompile(`\b([a-z]+)\d+\b`)
+	whitespace  = regexp.MustCompile(`\s+`)
+)
+
+// Normalize lowercases the tag, reduces separators to single spaces and drops the numbers
+// attached to words, so "Fake_Phishing5814" becomes "fake phishing". Token standards keep
+// their number: "ERC-20" becomes "erc20".
+func Normalize(tag string) string {
+	s := strings.ToLower(tag)
+	s = tokenStandards.ReplaceAllString(s, "erc$1")
+	s = separators.ReplaceAllString(s, " ")
+	s = numSuffixes.ReplaceAllStringFunc(s, func(w string) string {
+		if tokenStandards.MatchString(w) {
+			return w
+		}
+		return numSuffixes.ReplaceAllString(w, "$1")
+	})
+	return strings.TrimSpace(whitespace.ReplaceAllString(s, " "))
+}
+
+// Classify returns the category of a raw explorer tag, Other if none applies
+func Classify(tag string) Category {
+	n := Normalize(tag)
+	for _, r := range rules {
+		if r.re.MatchString(n) {
+			return r.category
+		}
+	}
+	return Other
+}
+
+// malicious categories are judgements against an address, reputable ones vouch for it
+var (
+	malicious = []Category{Scam, Exploit, Sanctioned, Mixer}
+	reputable = []Category{Exchange, Bridge, Stablecoin}
+)
+
+// Conflicts reports whether one category condemns an address the other vouches for
+func Conflicts(a, b Category) bool {
+	return (slices.Contains(malicious, a) && slices.Contains(reputable, b)) ||
+		(slices.Contains(reputable, a) && slices.Contains(malicious, b))
+}
+
+// IsMalicious reports whether the category is a judgement against the address
+func IsMalicious(c Category) bool {
+	return slices.Contains(malicious, c)
+}
@@ -0,0 +1,52 @@
+package taxonomy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "fake phishing", Normalize("Fake_Phishing5814"))
+	assert.Equal(t, "phish hack", Normalize(" Phish / Hack "))
+	assert.Equal(t, "yearn ydai exploiter", Normalize("yearn (ydai) exploiter"))
+	assert.Equal(t, "erc20", Normalize("ERC-20"))
+	assert.Equal(t, "erc1155 token", Normalize("ERC 1155 Token"))
+	assert.Equal(t, "binance hot wallet 20", Normalize("Binance: Hot Wallet 20"))
+}
+
+func TestClassify(t *testing.T) {
+	tests := map[string]Category{
+		"Phish / Hack":           Scam,
+		"Fake_Phishing5814":      Scam,
+		"heist":                  Exploit,
+		"yearn (ydai) exploiter": Exploit,
+		"OFAC Sanctions Lists":   Sanctioned,
+		"Tornado.Cash":           Mixer,
+		"Bridge":                 Bridge,
+		"Binance: Hot Wallet 20": Exchange,
+		"Exchange":               Exchange,
+		"Stablecoin":             Stablecoin,
+		"Token Contract":         Token,
+		"ERC-20":                 Token,
+		"ERC20":                  Token,
+		"erc721":                 Token,
+		"ERC-1155":               Token,
+		"ERC-20 Fake Token":      Scam,
+		"bitfinex":               Other,
+		"blocked":                Other,
+		"":                       Other,
+	}
+	for tag, expected := range tests {
+		assert.Equal(t, expected, Classify(tag), tag)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	assert.True(t, Conflicts(Scam, Exchange))
+	assert.True(t, Conflicts(Bridge, Exploit))
+	assert.False(t, Conflicts(Scam, Exploit))
+	assert.False(t, Conflicts(Exchange, Other))
+	assert.True(t, IsMalicious(Sanctioned))
+	assert.False(t, IsMalicious(Token))
+}